@@ -5,8 +5,15 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // embed the timezone database for QUIZ_TIMEZONE
 
 	"backendProject/internal/db"
+	"backendProject/internal/quiz"
+	"backendProject/internal/spotify"
 	"backendProject/routes"
 
 	"github.com/joho/godotenv"
@@ -26,7 +33,46 @@ func main() {
 		log.Fatalf("error connecting to redis: %v", err)
 	}
 
-	r := routes.NewRouter(rdb)
+	spotifyService := spotify.NewService(os.Getenv("SPOTIFY_CLIENT_ID"), os.Getenv("SPOTIFY_CLIENT_SECRET"))
+	quizOptions := []quiz.Option{quiz.WithLocation(quizLocation())}
+	if pickers := trackPickers(spotifyService); len(pickers) > 0 {
+		quizOptions = append(quizOptions, quiz.WithTrackPickers(pickers...))
+	}
+	if path := os.Getenv("QUIZ_CATEGORIES_FILE"); path != "" {
+		categories, err := quiz.LoadCategories(path)
+		if err != nil {
+			log.Fatalf("error loading quiz categories: %v", err)
+		}
+		quizOptions = append(quizOptions, quiz.WithCategories(categories...))
+	}
+	if seed, err := strconv.ParseUint(os.Getenv("QUIZ_SEED"), 10, 64); err == nil {
+		quizOptions = append(quizOptions, quiz.WithSeed(seed))
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("QUIZ_PRACTICE_TTL")); err == nil {
+		quizOptions = append(quizOptions, quiz.WithPracticeTTL(ttl))
+	}
+	correctThreshold, errCorrect := strconv.ParseFloat(os.Getenv("QUIZ_MATCH_CORRECT_THRESHOLD"), 64)
+	closeThreshold, errClose := strconv.ParseFloat(os.Getenv("QUIZ_MATCH_CLOSE_THRESHOLD"), 64)
+	if errCorrect == nil && errClose == nil {
+		quizOptions = append(quizOptions, quiz.WithMatchThresholds(correctThreshold, closeThreshold))
+	}
+	if modes := quizModes(); len(modes) > 0 {
+		quizOptions = append(quizOptions, quiz.WithModes(modes...))
+	}
+	quizOptions = append(quizOptions, quiz.WithBufferFilter(bufferFilter()))
+	if title := os.Getenv("QUIZ_SHARE_TITLE"); title != "" {
+		quizOptions = append(quizOptions, quiz.WithShareTitle(title))
+	}
+	if filter, err := strconv.ParseBool(os.Getenv("QUIZ_FILTER_EXPLICIT")); err == nil {
+		quizOptions = append(quizOptions, quiz.WithExplicitFilter(filter))
+	}
+	quizService := quiz.NewService(quiz.NewRepository(rdb), spotifyService, quizOptions...)
+
+	// generate the upcoming daily quizzes in the background
+	scheduler := quiz.NewScheduler(quizService, envInt("QUIZ_BUFFER_DAYS", 7), envInt("QUIZ_BUFFER_MIN_DAYS", 2), time.Hour)
+	go scheduler.Run(ctx)
+
+	r := routes.NewRouter(spotifyService, quizService)
 	port := os.Getenv("SERVER_PORT")
 	log.Printf("listening :%s", port)
 	err = http.ListenAndServe(":"+port, r)
@@ -34,3 +80,96 @@ func main() {
 		log.Fatalf("error starting server: %v", err)
 	}
 }
+
+// quizLocation loads the timezone where the daily quiz rolls over from
+// the QUIZ_TIMEZONE environment variable, falling back to UTC.
+func quizLocation() *time.Location {
+	name := os.Getenv("QUIZ_TIMEZONE")
+	if name == "" {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("error loading quiz timezone %q, using UTC: %v", name, err)
+		return time.UTC
+	}
+	return location
+}
+
+// quizModes returns the alternate game modes listed in the QUIZ_MODES
+// environment variable, the classic mode being always enabled.
+func quizModes() []string {
+	modes := []string{}
+	for _, mode := range envList("QUIZ_MODES") {
+		if !slices.Contains(quiz.Modes, mode) {
+			log.Fatalf("unknown quiz mode %q", mode)
+		}
+		if mode != quiz.ModeClassic {
+			modes = append(modes, mode)
+		}
+	}
+	return modes
+}
+
+// bufferFilter returns the categories, difficulties and modes whose daily quizzes are
+// generated ahead of time, listed in the QUIZ_BUFFER_CATEGORIES, QUIZ_BUFFER_DIFFICULTIES
+// and QUIZ_BUFFER_MODES environment variables. An empty list doesn't limit anything.
+func bufferFilter() quiz.BufferFilter {
+	filter := quiz.BufferFilter{
+		Categories:   envList("QUIZ_BUFFER_CATEGORIES"),
+		Difficulties: envList("QUIZ_BUFFER_DIFFICULTIES"),
+		Modes:        envList("QUIZ_BUFFER_MODES"),
+	}
+	for _, difficulty := range filter.Difficulties {
+		if !slices.Contains(quiz.Difficulties, difficulty) {
+			log.Fatalf("unknown quiz difficulty %q", difficulty)
+		}
+	}
+	for _, mode := range filter.Modes {
+		if !slices.Contains(quiz.Modes, mode) {
+			log.Fatalf("unknown quiz mode %q", mode)
+		}
+	}
+	return filter
+}
+
+// trackPickers creates the track pickers listed in the QUIZ_TRACK_PICKERS
+// environment variable, in order of preference. The curated picker uses
+// the public playlist linked in QUIZ_CURATED_PLAYLIST.
+func trackPickers(spotifyService spotify.Service) []quiz.TrackPicker {
+	names := envList("QUIZ_TRACK_PICKERS")
+	curatedPlaylist := os.Getenv("QUIZ_CURATED_PLAYLIST")
+
+	var pickers []quiz.TrackPicker
+	for _, name := range names {
+		picker, err := quiz.NewTrackPicker(name, spotifyService, curatedPlaylist)
+		if err != nil {
+			log.Printf("error creating track picker: %v", err)
+			continue
+		}
+		pickers = append(pickers, picker)
+	}
+	return pickers
+}
+
+// envList reads a comma separated environment variable.
+func envList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// envInt reads an integer environment variable, falling back
+// to the given value when it's missing or invalid.
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -2,14 +2,58 @@ package db
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"time"
 )
 
 var (
-	ErrNil = errors.New("no matching record found in redis database")
+	ErrNil             = errors.New("no matching record found in redis database")
+	ErrLockNotAcquired = errors.New("lock is held by another process")
 )
 
+// ScoredMember is a member of a sorted set along with its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// UnlockFunc releases a lock acquired with Database.Lock.
+type UnlockFunc func(ctx context.Context) error
+
 type Database interface {
 	GetObject(ctx context.Context, key string, obj interface{}) error
 	SetObject(ctx context.Context, key string, obj interface{}) error
+	// SetObjectWithTTL stores an object that expires after ttl,
+	// after which GetObject behaves as if the key doesn't exist.
+	SetObjectWithTTL(ctx context.Context, key string, obj interface{}, ttl time.Duration) error
+	// SetObjectIfAbsent stores an object unless the key already exists, so only the
+	// first of several writers stores it. It returns whether the object was stored.
+	SetObjectIfAbsent(ctx context.Context, key string, obj interface{}) (bool, error)
+	// Lock acquires a lock shared by every process using the database. The lock
+	// expires after ttl in case its holder dies, and ErrLockNotAcquired is
+	// returned while someone else holds it.
+	Lock(ctx context.Context, key string, ttl time.Duration) (UnlockFunc, error)
+	// SortedSetAdd adds a member to a sorted set, keeping the score of members
+	// already in the set. It returns whether the member was added.
+	SortedSetAdd(ctx context.Context, key, member string, score float64) (bool, error)
+	// SortedSetCount returns the number of members of a sorted set.
+	SortedSetCount(ctx context.Context, key string) (int64, error)
+	// SortedSetRange returns the members of a sorted set from start to stop, both
+	// inclusive, ordered by ascending score. A stop of -1 means the last member.
+	SortedSetRange(ctx context.Context, key string, start, stop int64) ([]ScoredMember, error)
+	// IncrBy atomically adds value to a counter, missing counters starting at 0.
+	// It returns the counter value after the increment.
+	IncrBy(ctx context.Context, key string, value int64) (int64, error)
+	// GetCounter returns the value of a counter, 0 when it doesn't exist.
+	GetCounter(ctx context.Context, key string) (int64, error)
+}
+
+// newLockToken generates a random value identifying the holder of a lock,
+// so a lock can only be released by whoever acquired it.
+func newLockToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -14,6 +15,15 @@ type RedisDB struct {
 	Client *redis.Client
 }
 
+// unlockScript deletes a lock only if it still holds the caller's token,
+// otherwise a lock that expired and was acquired by someone else could be released.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
 func NewRedisDB(ctx context.Context) (*RedisDB, error) {
 	redisUser := os.Getenv("REDIS_USER")
 	redisPassword := os.Getenv("REDIS_PASSWORD")
@@ -52,6 +62,40 @@ func (r *RedisDB) SetObject(ctx context.Context, key string, obj interface{}) er
 	return nil
 }
 
+// SetObjectWithTTL stores an object as a JSON string in Redis, expiring after ttl
+func (r *RedisDB) SetObjectWithTTL(ctx context.Context, key string, obj interface{}, ttl time.Duration) error {
+	// Marshal the object into JSON
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err // JSON marshaling error
+	}
+
+	// Set the JSON string in Redis, letting Redis delete it once expired
+	err = r.Client.Set(ctx, key, data, ttl).Err()
+	if err != nil {
+		return err // Redis error
+	}
+
+	return nil
+}
+
+// SetObjectIfAbsent stores an object as a JSON string in Redis using SET NX
+func (r *RedisDB) SetObjectIfAbsent(ctx context.Context, key string, obj interface{}) (bool, error) {
+	// Marshal the object into JSON
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return false, err // JSON marshaling error
+	}
+
+	// Set the JSON string in Redis, unless the key exists
+	stored, err := r.Client.SetNX(ctx, key, data, 0).Result() // 0 means no expiration
+	if err != nil {
+		return false, err // Redis error
+	}
+
+	return stored, nil
+}
+
 // GetObject retrieves a whole object from Redis by its key
 func (r *RedisDB) GetObject(ctx context.Context, key string, obj interface{}) error {
 	// Get the JSON string from Redis
@@ -71,3 +115,61 @@ func (r *RedisDB) GetObject(ctx context.Context, key string, obj interface{}) er
 
 	return nil // Successfully retrieved
 }
+
+// SortedSetAdd adds a member to a sorted set using ZADD NX
+func (r *RedisDB) SortedSetAdd(ctx context.Context, key, member string, score float64) (bool, error) {
+	added, err := r.Client.ZAddNX(ctx, key, redis.Z{Score: score, Member: member}).Result()
+	if err != nil {
+		return false, err // Redis error
+	}
+	return added == 1, nil
+}
+
+// SortedSetCount returns the number of members of a sorted set using ZCARD
+func (r *RedisDB) SortedSetCount(ctx context.Context, key string) (int64, error) {
+	return r.Client.ZCard(ctx, key).Result()
+}
+
+// SortedSetRange returns a range of a sorted set using ZRANGE WITHSCORES
+func (r *RedisDB) SortedSetRange(ctx context.Context, key string, start, stop int64) ([]ScoredMember, error) {
+	values, err := r.Client.ZRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err // Redis error
+	}
+
+	members := make([]ScoredMember, len(values))
+	for i, value := range values {
+		members[i] = ScoredMember{Member: fmt.Sprint(value.Member), Score: value.Score}
+	}
+	return members, nil
+}
+
+// IncrBy adds value to a counter using INCRBY
+func (r *RedisDB) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	return r.Client.IncrBy(ctx, key, value).Result()
+}
+
+// GetCounter returns the value of a counter stored by IncrBy
+func (r *RedisDB) GetCounter(ctx context.Context, key string) (int64, error) {
+	value, err := r.Client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil // Key does not exist
+	}
+	return value, err
+}
+
+// Lock acquires a distributed lock using SET NX with an expiration
+func (r *RedisDB) Lock(ctx context.Context, key string, ttl time.Duration) (UnlockFunc, error) {
+	token := newLockToken()
+	acquired, err := r.Client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err // Redis error
+	}
+	if !acquired {
+		return nil, ErrLockNotAcquired
+	}
+
+	return func(ctx context.Context) error {
+		return unlockScript.Run(ctx, r.Client, []string{key}, token).Err()
+	}, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -19,6 +21,10 @@ func NewSQLiteDB(ctx context.Context, dbFile string) (*SQLiteDB, error) {
 		return nil, err
 	}
 
+	// SQLite only allows a single writer, and every connection
+	// to an in memory database would open a different database
+	db.SetMaxOpenConns(1)
+
 	// Optionally, create a table if it doesn't exist
 	createTableSQL := `CREATE TABLE IF NOT EXISTS objects (
 		key TEXT PRIMARY KEY,
@@ -28,6 +34,40 @@ func NewSQLiteDB(ctx context.Context, dbFile string) (*SQLiteDB, error) {
 		return nil, err
 	}
 
+	// databases created before objects could expire lack the expires_at column
+	addExpiresAtSQL := `ALTER TABLE objects ADD COLUMN expires_at INTEGER;`
+	if _, err := db.Exec(addExpiresAtSQL); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, err
+	}
+
+	createLocksTableSQL := `CREATE TABLE IF NOT EXISTS locks (
+		key TEXT PRIMARY KEY,
+		token TEXT,
+		expires_at INTEGER
+	);`
+	if _, err := db.Exec(createLocksTableSQL); err != nil {
+		return nil, err
+	}
+
+	createSortedSetsTableSQL := `CREATE TABLE IF NOT EXISTS sorted_sets (
+		key TEXT,
+		member TEXT,
+		score REAL,
+		PRIMARY KEY (key, member)
+	);
+	CREATE INDEX IF NOT EXISTS sorted_sets_score ON sorted_sets (key, score, member);`
+	if _, err := db.Exec(createSortedSetsTableSQL); err != nil {
+		return nil, err
+	}
+
+	createCountersTableSQL := `CREATE TABLE IF NOT EXISTS counters (
+		key TEXT PRIMARY KEY,
+		value INTEGER
+	);`
+	if _, err := db.Exec(createCountersTableSQL); err != nil {
+		return nil, err
+	}
+
 	return &SQLiteDB{Client: db}, nil
 }
 
@@ -40,8 +80,8 @@ func (s *SQLiteDB) SetObject(ctx context.Context, key string, obj interface{}) e
 	}
 
 	// Upsert: insert the object or update if it already exists
-	_, err = s.Client.ExecContext(ctx, `INSERT INTO objects (key, value) VALUES (?, ?)
-		ON CONFLICT(key) DO UPDATE SET value=excluded.value`, key, data)
+	_, err = s.Client.ExecContext(ctx, `INSERT INTO objects (key, value, expires_at) VALUES (?, ?, NULL)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, expires_at=NULL`, key, data)
 	if err != nil {
 		return err // SQL error
 	}
@@ -49,11 +89,60 @@ func (s *SQLiteDB) SetObject(ctx context.Context, key string, obj interface{}) e
 	return nil
 }
 
+// SetObjectWithTTL stores an object as a JSON string in SQLite, expiring after ttl
+func (s *SQLiteDB) SetObjectWithTTL(ctx context.Context, key string, obj interface{}, ttl time.Duration) error {
+	// Marshal the object into JSON
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err // JSON marshaling error
+	}
+
+	now := time.Now()
+
+	// SQLite doesn't expire rows by itself, expired objects are purged on the way
+	_, err = s.Client.ExecContext(ctx, `DELETE FROM objects WHERE expires_at <= ?`, now.UnixMilli())
+	if err != nil {
+		return err // SQL error
+	}
+
+	_, err = s.Client.ExecContext(ctx, `INSERT INTO objects (key, value, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, expires_at=excluded.expires_at`, key, data, now.Add(ttl).UnixMilli())
+	if err != nil {
+		return err // SQL error
+	}
+
+	return nil
+}
+
+// SetObjectIfAbsent stores an object as a JSON string in SQLite, unless
+// the key exists, taking over objects that expired
+func (s *SQLiteDB) SetObjectIfAbsent(ctx context.Context, key string, obj interface{}) (bool, error) {
+	// Marshal the object into JSON
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return false, err // JSON marshaling error
+	}
+
+	res, err := s.Client.ExecContext(ctx, `INSERT INTO objects (key, value, expires_at) VALUES (?, ?, NULL)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, expires_at=NULL
+		WHERE objects.expires_at <= ?`, key, data, time.Now().UnixMilli())
+	if err != nil {
+		return false, err // SQL error
+	}
+
+	stored, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return stored == 1, nil
+}
+
 // GetObject retrieves a whole object from SQLite by its key
 func (s *SQLiteDB) GetObject(ctx context.Context, key string, obj interface{}) error {
 	// Get the JSON string from SQLite
 	var value string
-	err := s.Client.QueryRowContext(ctx, `SELECT value FROM objects WHERE key = ?`, key).Scan(&value)
+	err := s.Client.QueryRowContext(ctx, `SELECT value FROM objects
+		WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)`, key, time.Now().UnixMilli()).Scan(&value)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil // Key does not exist
@@ -70,6 +159,99 @@ func (s *SQLiteDB) GetObject(ctx context.Context, key string, obj interface{}) e
 	return nil // Successfully retrieved
 }
 
+// Lock acquires a lock stored in the locks table, taking
+// over locks whose holder let them expire
+func (s *SQLiteDB) Lock(ctx context.Context, key string, ttl time.Duration) (UnlockFunc, error) {
+	token := newLockToken()
+	now := time.Now()
+
+	res, err := s.Client.ExecContext(ctx, `INSERT INTO locks (key, token, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET token=excluded.token, expires_at=excluded.expires_at
+		WHERE locks.expires_at <= ?`, key, token, now.Add(ttl).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return nil, err // SQL error
+	}
+
+	acquired, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if acquired == 0 {
+		return nil, ErrLockNotAcquired
+	}
+
+	return func(ctx context.Context) error {
+		_, err := s.Client.ExecContext(ctx, `DELETE FROM locks WHERE key = ? AND token = ?`, key, token)
+		return err
+	}, nil
+}
+
+// SortedSetAdd adds a member to the sorted_sets table, leaving existing members untouched
+func (s *SQLiteDB) SortedSetAdd(ctx context.Context, key, member string, score float64) (bool, error) {
+	res, err := s.Client.ExecContext(ctx, `INSERT INTO sorted_sets (key, member, score) VALUES (?, ?, ?)
+		ON CONFLICT(key, member) DO NOTHING`, key, member, score)
+	if err != nil {
+		return false, err // SQL error
+	}
+
+	added, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return added == 1, nil
+}
+
+// SortedSetCount returns the number of members of a sorted set
+func (s *SQLiteDB) SortedSetCount(ctx context.Context, key string) (int64, error) {
+	var count int64
+	err := s.Client.QueryRowContext(ctx, `SELECT COUNT(*) FROM sorted_sets WHERE key = ?`, key).Scan(&count)
+	return count, err
+}
+
+// SortedSetRange returns a range of a sorted set, ordered like Redis by score and then member
+func (s *SQLiteDB) SortedSetRange(ctx context.Context, key string, start, stop int64) ([]ScoredMember, error) {
+	limit := int64(-1) // no limit
+	if stop >= 0 {
+		limit = max(stop-start+1, 0)
+	}
+
+	rows, err := s.Client.QueryContext(ctx, `SELECT member, score FROM sorted_sets WHERE key = ?
+		ORDER BY score, member LIMIT ? OFFSET ?`, key, limit, start)
+	if err != nil {
+		return nil, err // SQL error
+	}
+	defer rows.Close()
+
+	members := []ScoredMember{}
+	for rows.Next() {
+		var member ScoredMember
+		if err := rows.Scan(&member.Member, &member.Score); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// IncrBy adds value to a counter in the counters table
+func (s *SQLiteDB) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	var counter int64
+	err := s.Client.QueryRowContext(ctx, `INSERT INTO counters (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = value + excluded.value
+		RETURNING value`, key, value).Scan(&counter)
+	return counter, err
+}
+
+// GetCounter returns the value of a counter, 0 when it doesn't exist
+func (s *SQLiteDB) GetCounter(ctx context.Context, key string) (int64, error) {
+	var counter int64
+	err := s.Client.QueryRowContext(ctx, `SELECT value FROM counters WHERE key = ?`, key).Scan(&counter)
+	if err == sql.ErrNoRows {
+		return 0, nil // Key does not exist
+	}
+	return counter, err
+}
+
 // Close closes the SQLite database connection
 func (s *SQLiteDB) Close() error {
 	return s.Client.Close()
@@ -0,0 +1,286 @@
+package quiz
+
+import (
+	"backendProject/internal/spotify"
+	"errors"
+	"log"
+	"math/rand/v2"
+)
+
+// Hint levels of the album mode, where the track count of the album is
+// shown from the start and each wrong guess unlocks the next hint.
+// A snippet of the picked track is unlocked last, as in the classic mode.
+const (
+	albumHintGenres      = 1
+	albumHintReleaseYear = 2
+	albumHintLabel       = 3
+	albumHintArtists     = 4
+)
+
+const (
+	// maxAlbumPicks bounds the tracks picked until one is from a studio album.
+	maxAlbumPicks = 5
+	// minAlbumTracks is the track count below which a release is rather an EP.
+	minAlbumTracks = 5
+	// albumTypeAlbum is the Spotify album type of studio albums,
+	// as opposed to singles and compilations.
+	albumTypeAlbum = "album"
+)
+
+var ErrNoAlbumPicked = errors.New("no track from a studio album found")
+
+// generateAlbumQuiz picks a random album through the track pickers, so the category
+// and difficulty constrain the album as they do the classic tracks. Only studio albums
+// are kept, the singles, EPs and compilations being hardly known by their names.
+//
+// Parameters:
+//   - r: The random source driving every choice of the generation.
+//   - category: The category constraining the picked track.
+//
+// Returns:
+//   - A Quiz object whose answer is the album, with the picked track to play as a snippet.
+//   - An error if the quiz generation fails or no track from a studio album is picked.
+func (s *service) generateAlbumQuiz(r *rand.Rand, category Category) (Quiz, error) {
+	track, album, err := s.pickAlbum(r, category)
+	if err != nil {
+		return Quiz{}, err
+	}
+
+	artists, err := s.spotifyService.GetArtists(artistIDs(album.Artists, 5))
+	if err != nil {
+		log.Printf("Error getting artists of album %s: %v", album.ID, err)
+		return Quiz{}, err
+	}
+
+	quiz := buildQuiz(track, artists.Artists)
+	quiz.Album = mapAlbum(album)
+	quiz.DifficultyScore = AlbumDifficultyScore(album, artists.Artists)
+
+	log.Printf("Generated album Quiz with Album: %s, Track: %s", quiz.Album.Name, quiz.Track.Name)
+	return quiz, nil
+}
+
+// pickAlbum picks tracks until one is from a studio album, then fetches the full
+// album, whose label and popularity aren't returned along with its tracks.
+//
+// Parameters:
+//   - r: The random source driving every choice of the generation.
+//   - category: The category constraining the picked track.
+//
+// Returns:
+//   - The picked Spotify track and its full album.
+//   - An error if the requests fail or no track from a studio album is picked.
+func (s *service) pickAlbum(r *rand.Rand, category Category) (spotify.Track, spotify.Album, error) {
+	for attempts := 0; attempts < maxAlbumPicks; attempts++ {
+		track, err := pickTrack(s.pickers, r, category)
+		if err != nil {
+			log.Printf("Error picking a track: %v", err)
+			return spotify.Track{}, spotify.Album{}, err
+		}
+		// the album of the track already tells its type and track count
+		if !isStudioAlbum(track.Album) {
+			log.Printf("Picked track %s is from a %s of %d tracks, retrying", track.ID, track.Album.AlbumType, track.Album.TotalTracks)
+			continue
+		}
+
+		albums, err := s.spotifyService.GetAlbums([]string{track.Album.ID})
+		if err != nil {
+			log.Printf("Error getting album %s: %v", track.Album.ID, err)
+			return spotify.Track{}, spotify.Album{}, err
+		}
+		if len(albums.Albums) == 0 || !isStudioAlbum(albums.Albums[0]) {
+			continue
+		}
+		return track, albums.Albums[0], nil
+	}
+
+	log.Printf("No track from a studio album picked after %d attempts", maxAlbumPicks)
+	return spotify.Track{}, spotify.Album{}, ErrNoAlbumPicked
+}
+
+// isStudioAlbum tells whether an album is a studio album rather than a single, an EP or a compilation.
+func isStudioAlbum(album spotify.Album) bool {
+	return album.AlbumType == albumTypeAlbum && album.TotalTracks >= minAlbumTracks
+}
+
+// albumClues sets the hints of a quiz in album mode unlocked by a session.
+func (q Quiz) albumClues(clues *Clues, session Session) {
+	level := session.HintLevel()
+	clues.TotalTracks = q.Album.TotalTracks
+	if level >= albumHintGenres {
+		clues.Genres = q.Genres()
+	}
+	if level >= albumHintReleaseYear {
+		clues.ReleaseYear = releaseYear(q.Album.ReleaseDate)
+	}
+	if level >= albumHintLabel {
+		clues.Label = q.Album.Label
+	}
+	if level >= albumHintArtists {
+		clues.Artists = make([]string, len(q.Artists))
+		for i, artist := range q.Artists {
+			clues.Artists[i] = artist.Name
+		}
+	}
+	if level >= HintAudioPreview {
+		clues.AudioSeconds = q.audioSeconds(session)
+	}
+	if session.Finished() {
+		clues.AlbumImage = q.Album.Image
+		clues.AudioPreview = q.Track.AudioPreview
+	}
+}
+
+// findAlbum retrieves the guessed album from Spotify's API, either by its ID
+// or by the first search result for its name.
+//
+// Parameters:
+//   - guess: The guessed album ID or album name.
+//
+// Returns:
+//   - A Spotify album object containing the guessed album data.
+//   - An error if the album is not found or the request fails.
+func (s *service) findAlbum(guess Guess) (spotify.Album, error) {
+	if guess.AlbumID != "" {
+		albums, err := s.spotifyService.GetAlbums([]string{guess.AlbumID})
+		if err != nil {
+			log.Printf("Error getting guessed album: %v", err)
+			return spotify.Album{}, ErrAlbumNotFound
+		}
+		if len(albums.Albums) == 0 || albums.Albums[0].ID == "" {
+			return spotify.Album{}, ErrAlbumNotFound
+		}
+		return albums.Albums[0], nil
+	}
+
+	search, err := s.spotifyService.Search(guess.AlbumName, "album")
+	if err != nil {
+		log.Printf("Error searching for guessed album: %v", err)
+		return spotify.Album{}, err
+	}
+	if len(search.Albums.Items) == 0 {
+		return spotify.Album{}, ErrAlbumNotFound
+	}
+	return search.Albums.Items[0], nil
+}
+
+// checkAlbumGuess compares a guessed album with the answer of a quiz in album mode.
+// Names are matched loosely like the track titles of the classic mode, so another
+// release of the answer by the same artists, like a remaster, is still correct.
+//
+// Parameters:
+//   - quiz: The quiz holding the answer.
+//   - guess: The guessed album ID or typed name.
+//
+// Returns:
+//   - A GuessResult object containing the feedback on the artists, genres and release year.
+//   - An error if the guess has no album, the guessed album is not found or the request fails.
+func (s *service) checkAlbumGuess(quiz Quiz, guess Guess) (GuessResult, error) {
+	typed := guess.AlbumID == ""
+	if typed && guess.AlbumName == "" {
+		return GuessResult{}, ErrEmptyGuess
+	}
+
+	// a typed name matching the answer's needs no lookup
+	nameMatch := s.matcher.match(guess.AlbumName, quiz.Album.Name)
+	if typed && nameMatch == matchCorrect {
+		return albumAnswerResult(quiz), nil
+	}
+
+	album, err := s.findAlbum(guess)
+	if errors.Is(err, ErrAlbumNotFound) && typed && nameMatch == matchClose {
+		// a close name with a typo might not be found at all
+		return GuessResult{AlbumName: guess.AlbumName, SharedGenres: []string{}, Close: true}, nil
+	}
+	if err != nil {
+		return GuessResult{}, err
+	}
+
+	artists, err := s.spotifyService.GetArtists(artistIDs(album.Artists, 5))
+	if err != nil {
+		log.Printf("Error getting artists from guessed album: %v", err)
+		return GuessResult{}, err
+	}
+
+	result := compareAlbum(quiz, album, artists.Artists)
+	if result.Correct {
+		return result, nil
+	}
+
+	if !typed {
+		nameMatch = s.matcher.match(album.Name, quiz.Album.Name)
+	}
+	switch nameMatch {
+	case matchCorrect:
+		// an album with the same name by someone else is only close
+		result.Correct = result.SameArtist
+		result.Close = !result.SameArtist
+	case matchClose:
+		result.Close = true
+	}
+	return result, nil
+}
+
+// albumAnswerResult returns the feedback of a guess matching the answer of a quiz in album mode.
+func albumAnswerResult(quiz Quiz) GuessResult {
+	sharedArtists := make([]string, len(quiz.Artists))
+	for i, artist := range quiz.Artists {
+		sharedArtists[i] = artist.Name
+	}
+	return GuessResult{
+		Correct:       true,
+		AlbumID:       quiz.Album.ID,
+		AlbumName:     quiz.Album.Name,
+		SameArtist:    true,
+		SameAlbum:     true,
+		SharedArtists: sharedArtists,
+		SharedGenres:  quiz.Genres(),
+		ReleaseYear:   YearEqual,
+	}
+}
+
+// compareAlbum compares a guessed album and its artists against a quiz in album mode.
+//
+// Parameters:
+//   - quiz: The quiz holding the answer.
+//   - album: The guessed Spotify album.
+//   - artists: The artists of the guessed album.
+//
+// Returns:
+//   - A GuessResult object containing the feedback on the artists, genres and release year.
+func compareAlbum(quiz Quiz, album spotify.Album, artists []spotify.Artist) GuessResult {
+	quizArtists := make(map[string]bool)
+	for _, artist := range quiz.Artists {
+		quizArtists[artist.ID] = true
+	}
+
+	sharedArtists := []string{}
+	for _, artist := range album.Artists {
+		if quizArtists[artist.ID] {
+			sharedArtists = append(sharedArtists, artist.Name)
+		}
+	}
+
+	quizGenres := make(map[string]bool)
+	for _, genre := range quiz.Genres() {
+		quizGenres[genre] = true
+	}
+
+	sharedGenres := []string{}
+	for _, genre := range (Quiz{Artists: mapArtists(artists)}).Genres() {
+		if quizGenres[genre] {
+			sharedGenres = append(sharedGenres, genre)
+		}
+	}
+
+	return GuessResult{
+		Correct:       album.ID == quiz.Album.ID,
+		AlbumID:       album.ID,
+		AlbumName:     album.Name,
+		SameArtist:    len(sharedArtists) > 0,
+		SameAlbum:     album.ID == quiz.Album.ID,
+		SharedArtists: sharedArtists,
+		SharedGenres:  sharedGenres,
+		ReleaseYear:   compareYears(releaseYear(quiz.Album.ReleaseDate), releaseYear(album.ReleaseDate)),
+	}
+}
@@ -0,0 +1,261 @@
+package quiz
+
+import (
+	"backendProject/internal/spotify"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// Hint levels of the artist mode, where the follower count of the
+// artist is shown from the start and each wrong guess unlocks the next hint.
+const (
+	artistHintGenres  = 1
+	artistHintRelated = 2 // one more related artist with each wrong guess after this one
+	artistHintAudio   = 3 // snippet of one of the artist's top tracks
+)
+
+// maxRelatedClues is the number of related artists revealed as hints.
+const maxRelatedClues = 4
+
+// generateArtistQuiz picks a random artist through the track pickers, so the
+// category and difficulty constrain the artist as they do the classic tracks,
+// along with one of their top tracks to play as a snippet and their related artists.
+//
+// Parameters:
+//   - r: The random source driving every choice of the generation.
+//   - category: The category constraining the picked track.
+//
+// Returns:
+//   - A Quiz object whose answer is its only artist.
+//   - An error if the quiz generation fails.
+func (s *service) generateArtistQuiz(r *rand.Rand, category Category) (Quiz, error) {
+	track, err := pickTrack(s.pickers, r, category)
+	if err != nil {
+		log.Printf("Error picking a track: %v", err)
+		return Quiz{}, err
+	}
+
+	ids := artistIDs(track.Album.Artists, 1)
+	if len(ids) == 0 {
+		return Quiz{}, errors.New("picked track has no artist")
+	}
+	artists, err := s.spotifyService.GetArtists(ids)
+	if err != nil {
+		log.Printf("Error getting artist from random song: %v", err)
+		return Quiz{}, err
+	}
+	if len(artists.Artists) == 0 {
+		return Quiz{}, fmt.Errorf("artist %s of the picked track not found", ids[0])
+	}
+	artist := artists.Artists[0]
+
+	// the snippet comes from a top track, falling back to the picked one
+	topTracks, err := s.spotifyService.GetArtistTopTracks(artist.ID, category.options()...)
+	if err != nil {
+		log.Printf("Error getting top tracks of artist %s: %v", artist.ID, err)
+		return Quiz{}, err
+	}
+	withPreview := slices.DeleteFunc(topTracks.Tracks, func(t spotify.Track) bool { return t.PreviewURL == "" })
+	if len(withPreview) > 0 {
+		track = withPreview[r.IntN(len(withPreview))]
+	}
+
+	related, err := s.spotifyService.GetRelatedArtists(artist.ID)
+	if err != nil {
+		log.Printf("Error getting related artists of artist %s: %v", artist.ID, err)
+		return Quiz{}, err
+	}
+
+	quiz := buildQuiz(track, []spotify.Artist{artist})
+	quiz.DifficultyScore = ArtistDifficultyScore(artist)
+	// the closest related artists are revealed last
+	for i := min(len(related.Artists), maxRelatedClues) - 1; i >= 0; i-- {
+		quiz.RelatedArtists = append(quiz.RelatedArtists, related.Artists[i].Name)
+	}
+
+	log.Printf("Generated artist Quiz with Artist: %s, Track: %s", artist.Name, quiz.Track.Name)
+	return quiz, nil
+}
+
+// artistClues sets the hints of a quiz in artist mode unlocked by a session.
+func (q Quiz) artistClues(clues *Clues, session Session) {
+	if len(q.Artists) == 0 {
+		return
+	}
+
+	level := session.HintLevel()
+	clues.Followers = followerRange(q.Artists[0].Followers)
+	if level >= artistHintGenres {
+		clues.Genres = q.Genres()
+	}
+	if level >= artistHintRelated {
+		clues.RelatedArtists = q.RelatedArtists[:min(level-artistHintRelated+1, len(q.RelatedArtists))]
+	}
+	if level >= artistHintAudio {
+		clues.AudioSeconds = q.audioSeconds(session)
+	}
+	if session.Finished() {
+		clues.Artists = []string{q.Artists[0].Name}
+		clues.AudioPreview = q.Track.AudioPreview
+	}
+}
+
+// followerRange returns the order of magnitude of a follower count, e.g. "1M-10M".
+func followerRange(followers int) string {
+	if followers < 10 {
+		return "0-10"
+	}
+	low := int(math.Pow10(int(math.Log10(float64(followers)))))
+	return compactCount(low) + "-" + compactCount(low*10)
+}
+
+// compactCount formats a power of ten with a thousands suffix, e.g. 10K or 1B.
+func compactCount(n int) string {
+	switch {
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%dB", n/1_000_000_000)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%dM", n/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%dK", n/1_000)
+	default:
+		return fmt.Sprint(n)
+	}
+}
+
+// findArtist retrieves the guessed artist from Spotify's API, either by its ID
+// or by the first search result for its name.
+//
+// Parameters:
+//   - guess: The guessed artist ID or artist name.
+//
+// Returns:
+//   - A Spotify artist object containing the guessed artist data.
+//   - An error if the artist is not found or the request fails.
+func (s *service) findArtist(guess Guess) (spotify.Artist, error) {
+	if guess.ArtistID != "" {
+		artists, err := s.spotifyService.GetArtists([]string{guess.ArtistID})
+		if err != nil {
+			log.Printf("Error getting guessed artist: %v", err)
+			return spotify.Artist{}, ErrArtistNotFound
+		}
+		if len(artists.Artists) == 0 || artists.Artists[0].ID == "" {
+			return spotify.Artist{}, ErrArtistNotFound
+		}
+		return artists.Artists[0], nil
+	}
+
+	search, err := s.spotifyService.Search(guess.ArtistName, "artist")
+	if err != nil {
+		log.Printf("Error searching for guessed artist: %v", err)
+		return spotify.Artist{}, err
+	}
+	if len(search.Artists.Items) == 0 {
+		return spotify.Artist{}, ErrArtistNotFound
+	}
+	return search.Artists.Items[0], nil
+}
+
+// checkArtistGuess compares a guessed artist with the answer of a quiz in artist mode.
+// Names are matched loosely like the track titles of the classic mode.
+//
+// Parameters:
+//   - quiz: The quiz holding the answer.
+//   - guess: The guessed artist ID or typed name.
+//
+// Returns:
+//   - A GuessResult object containing the feedback on the genres and followers.
+//   - An error if the guess has no artist, the guessed artist is not found or the request fails.
+func (s *service) checkArtistGuess(quiz Quiz, guess Guess) (GuessResult, error) {
+	typed := guess.ArtistID == ""
+	if typed && guess.ArtistName == "" {
+		return GuessResult{}, ErrEmptyGuess
+	}
+	if len(quiz.Artists) == 0 {
+		return GuessResult{}, ErrQuizNotFound
+	}
+
+	// a typed name matching the answer's needs no lookup
+	nameMatch := s.matcher.match(guess.ArtistName, quiz.Artists[0].Name)
+	if typed && nameMatch == matchCorrect {
+		return artistAnswerResult(quiz), nil
+	}
+
+	artist, err := s.findArtist(guess)
+	if errors.Is(err, ErrArtistNotFound) && typed && nameMatch == matchClose {
+		// a close name with a typo might not be found at all
+		return GuessResult{ArtistName: guess.ArtistName, SharedGenres: []string{}, Close: true}, nil
+	}
+	if err != nil {
+		return GuessResult{}, err
+	}
+
+	result := compareArtist(quiz, artist)
+	if result.Correct {
+		return result, nil
+	}
+	if !typed {
+		nameMatch = s.matcher.match(artist.Name, quiz.Artists[0].Name)
+	}
+	// another artist with the answer's name is only close
+	result.Close = nameMatch != matchWrong
+	return result, nil
+}
+
+// artistAnswerResult returns the feedback of a guess matching the answer of a quiz in artist mode.
+func artistAnswerResult(quiz Quiz) GuessResult {
+	return GuessResult{
+		Correct:      true,
+		ArtistID:     quiz.Artists[0].ID,
+		ArtistName:   quiz.Artists[0].Name,
+		SameArtist:   true,
+		SharedGenres: quiz.Genres(),
+		Followers:    FollowersEqual,
+	}
+}
+
+// compareArtist compares a guessed artist against a quiz in artist mode.
+//
+// Parameters:
+//   - quiz: The quiz holding the answer.
+//   - artist: The guessed Spotify artist.
+//
+// Returns:
+//   - A GuessResult object containing the feedback on the genres and followers.
+func compareArtist(quiz Quiz, artist spotify.Artist) GuessResult {
+	answer := quiz.Artists[0]
+
+	sharedGenres := []string{}
+	for _, genre := range artist.Genres {
+		if slices.Contains(answer.Genres, genre) {
+			sharedGenres = append(sharedGenres, genre)
+		}
+	}
+
+	distance := math.Abs(math.Log10(float64(answer.Followers)+1) - math.Log10(float64(artist.Followers.Total)+1))
+	return GuessResult{
+		Correct:          artist.ID == answer.ID,
+		ArtistID:         artist.ID,
+		ArtistName:       artist.Name,
+		SameArtist:       artist.ID == answer.ID,
+		SharedGenres:     sharedGenres,
+		Followers:        compareFollowers(answer.Followers, artist.Followers.Total),
+		FollowerDistance: math.Round(distance*10) / 10,
+	}
+}
+
+// compareFollowers tells whether the answer has more followers than the guessed artist.
+func compareFollowers(answer, guess int) string {
+	switch {
+	case answer > guess:
+		return FollowersHigher
+	case answer < guess:
+		return FollowersLower
+	default:
+		return FollowersEqual
+	}
+}
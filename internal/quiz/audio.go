@@ -0,0 +1,278 @@
+package quiz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	// previewSeconds is the length of the Spotify audio previews.
+	previewSeconds = 30
+	// hintAudioSeconds is the length of the clip unlocked by the audio preview hint,
+	// the whole preview being unlocked once the quiz is finished.
+	hintAudioSeconds = 10
+	// previewCacheTTL is how long a downloaded preview is kept, most
+	// players asking for it on the day of the quiz.
+	previewCacheTTL = 24 * time.Hour
+	maxPreviewSize  = 2 << 20 // 30 seconds of MP3 at 320 kbps is about 1.2 MB
+
+	audioContentType = "audio/mpeg"
+)
+
+var errInvalidMP3 = errors.New("audio preview has no MP3 frames")
+
+// previewAudio is the audio preview of a quiz, downloaded on the first request so
+// players never see its URL, which identifies the track.
+type previewAudio struct {
+	Data      []byte    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Audio is a clip of the audio preview of a quiz, as played to a player.
+type Audio struct {
+	Seconds     int
+	ContentType string
+	Data        []byte
+}
+
+// audioSeconds returns the length of the audio clip the session has unlocked,
+// 0 before the audio preview hint, which comes earlier in artist mode and
+// right away in audio mode, or wherever the custom hint ladder puts it.
+func (q Quiz) audioSeconds(session Session) int {
+	hint := q.unlockLevel(HintAudioPreview)
+	if q.Mode == ModeArtist {
+		hint = artistHintAudio
+	}
+
+	switch {
+	case session.Finished():
+		return previewSeconds
+	case q.Mode == ModeAudio:
+		return audioClipSeconds[session.HintLevel()]
+	case session.HintLevel() >= hint:
+		return hintAudioSeconds
+	default:
+		return 0
+	}
+}
+
+// GetAudio returns the first seconds of the audio preview of a quiz, trimmed to whole
+// MP3 frames and stripped of its tags, as long as the session has unlocked them.
+//
+// Parameters:
+//   - quizID: The ID of the quiz being played.
+//   - sessionID: The ID of the player's session.
+//   - seconds: The length of the clip, or 0 for the longest clip unlocked.
+//
+// Returns:
+//   - An Audio object containing the MP3 clip.
+//   - An error if the quiz has no preview, the clip is locked or the preview can't be downloaded.
+func (s *service) GetAudio(ctx context.Context, quizID, sessionID string, seconds int) (Audio, error) {
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return Audio{}, err
+	}
+	if quiz.Track.AudioPreview == "" {
+		return Audio{}, ErrAudioNotFound
+	}
+
+	session, err := s.getSession(ctx, sessionID, quiz)
+	if err != nil {
+		return Audio{}, err
+	}
+	unlocked := quiz.audioSeconds(session)
+	if seconds == 0 {
+		seconds = unlocked
+	}
+	if seconds <= 0 || seconds > unlocked {
+		return Audio{}, ErrAudioLocked
+	}
+
+	preview, err := s.getPreview(ctx, quiz)
+	if err != nil {
+		return Audio{}, err
+	}
+	clip, err := trimMP3(preview.Data, seconds)
+	if err != nil {
+		log.Printf("Error trimming audio preview of quiz %s: %v", quiz.ID, err)
+		return Audio{}, err
+	}
+	return Audio{Seconds: seconds, ContentType: audioContentType, Data: clip}, nil
+}
+
+// getPreview returns the audio preview of a quiz, downloading it on the first
+// request and keeping it for a while. Concurrent first requests share one download.
+func (s *service) getPreview(ctx context.Context, quiz Quiz) (previewAudio, error) {
+	preview, err := s.repository.GetPreview(ctx, quiz.ID)
+	if err != nil {
+		log.Printf("Error getting audio preview of quiz %s: %v", quiz.ID, err)
+		return previewAudio{}, err
+	}
+	if !preview.CreatedAt.IsZero() {
+		return preview, nil
+	}
+
+	return s.previews.Do(quiz.ID, func() (previewAudio, error) {
+		ctx := context.WithoutCancel(ctx)
+		data, err := s.download(ctx, quiz.Track.AudioPreview, maxPreviewSize)
+		if err != nil {
+			log.Printf("Error downloading audio preview of quiz %s: %v", quiz.ID, err)
+			return previewAudio{}, err
+		}
+
+		return s.cachePreview(ctx, quiz, data), nil
+	})
+}
+
+// cachePreview keeps the audio preview of a quiz for a while, no longer than the quiz
+// when it's a practice one. The preview can still be played when it can't be stored,
+// it's downloaded again on the next request.
+func (s *service) cachePreview(ctx context.Context, quiz Quiz, data []byte) previewAudio {
+	preview := previewAudio{Data: data, CreatedAt: s.now()}
+	ttl := previewCacheTTL
+	if quiz.ExpiresAt != nil {
+		ttl = max(min(ttl, quiz.ExpiresAt.Sub(s.now())), time.Second)
+	}
+	if err := s.repository.SetPreview(ctx, quiz.ID, preview, ttl); err != nil {
+		log.Printf("Error setting audio preview of quiz %s: %v", quiz.ID, err)
+	}
+	return preview
+}
+
+// MPEG audio versions, as encoded in the frame headers.
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+var (
+	// layer III bitrates in kbps by bitrate index, for MPEG-1 and for MPEG-2 and 2.5
+	mpeg1Bitrates = [15]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mpeg2Bitrates = [15]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+	// sample rates in Hz by sample rate index, for each version
+	sampleRates = map[int][3]int{
+		mpeg1:  {44100, 48000, 32000},
+		mpeg2:  {22050, 24000, 16000},
+		mpeg25: {11025, 12000, 8000},
+	}
+)
+
+// mp3Frame is what the header of an MP3 frame tells about it.
+type mp3Frame struct {
+	length   int     // in bytes, including the header
+	duration float64 // in seconds
+}
+
+// parseFrameHeader reads the header of an MPEG layer III frame.
+//
+// Parameters:
+//   - data: The bytes starting with the frame.
+//
+// Returns:
+//   - The length and duration of the frame.
+//   - Whether data starts with a valid frame header.
+func parseFrameHeader(data []byte) (mp3Frame, bool) {
+	if len(data) < 4 || data[0] != 0xff || data[1]&0xe0 != 0xe0 {
+		return mp3Frame{}, false
+	}
+
+	version := int(data[1]>>3) & 3
+	layer := int(data[1]>>1) & 3
+	bitrateIndex := int(data[2] >> 4)
+	sampleRateIndex := int(data[2]>>2) & 3
+	padding := int(data[2]>>1) & 1
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	sampleRate := sampleRates[version][sampleRateIndex]
+	if version == mpeg1 {
+		bitrate := mpeg1Bitrates[bitrateIndex] * 1000
+		return mp3Frame{length: 144*bitrate/sampleRate + padding, duration: 1152 / float64(sampleRate)}, true
+	}
+	bitrate := mpeg2Bitrates[bitrateIndex] * 1000
+	return mp3Frame{length: 72*bitrate/sampleRate + padding, duration: 576 / float64(sampleRate)}, true
+}
+
+// mp3Frames calls fn with each whole MP3 frame of a file, in order, until fn returns false.
+// The ID3 tags, which can name the track, and the Xing or Info frame, which tells
+// the length of the whole file, are skipped.
+//
+// Parameters:
+//   - data: The MP3 file.
+//   - fn: The function called with the bytes and the duration of each frame.
+func mp3Frames(data []byte, fn func(body []byte, duration float64) bool) {
+	pos := 0
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+		pos = 10 + size
+		if data[5]&0x10 != 0 {
+			pos += 10 // footer
+		}
+	}
+
+	first := true
+	for pos < len(data) {
+		frame, ok := parseFrameHeader(data[pos:])
+		if !ok {
+			// skip the bytes until the next frame
+			pos++
+			continue
+		}
+		if pos+frame.length > len(data) {
+			return
+		}
+
+		body := data[pos : pos+frame.length]
+		pos += frame.length
+		if first {
+			first = false
+			header := body[:min(len(body), 64)]
+			if bytes.Contains(header, []byte("Xing")) || bytes.Contains(header, []byte("Info")) {
+				continue
+			}
+		}
+		if !fn(body, frame.duration) {
+			return
+		}
+	}
+}
+
+// trimMP3 keeps the first whole MP3 frames covering the given number of seconds,
+// without the tags and the Xing or Info frame. (see mp3Frames)
+//
+// Parameters:
+//   - data: The MP3 file.
+//   - seconds: The length of the clip.
+//
+// Returns:
+//   - The MP3 clip.
+//   - An error if the file has no MP3 frames.
+func trimMP3(data []byte, seconds int) ([]byte, error) {
+	var clip bytes.Buffer
+	duration := 0.0
+	mp3Frames(data, func(body []byte, frameDuration float64) bool {
+		clip.Write(body)
+		duration += frameDuration
+		return duration < float64(seconds)
+	})
+
+	if clip.Len() == 0 {
+		return nil, errInvalidMP3
+	}
+	return clip.Bytes(), nil
+}
+
+// mp3Duration returns the length in seconds of the whole MP3 frames of a file.
+func mp3Duration(data []byte) float64 {
+	duration := 0.0
+	mp3Frames(data, func(_ []byte, frameDuration float64) bool {
+		duration += frameDuration
+		return true
+	})
+	return duration
+}
@@ -0,0 +1,125 @@
+package quiz
+
+import (
+	"backendProject/internal/spotify"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minAutocompleteLength  = 2
+	autocompleteLimit      = 10
+	autocompleteCacheTTL   = 10 * time.Minute
+	maxAutocompleteEntries = 1000
+)
+
+// Suggestion is a track offered to the player while typing a guess. It holds
+// nothing that depends on the quiz being played, so it can't leak the answer.
+type Suggestion struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Artist string `json:"artist"`
+	Image  string `json:"image,omitempty"` // smallest album cover
+}
+
+// suggestionCache keeps the suggestions of recent queries for a while, since
+// players typing the same song send the same prefixes over and over.
+type suggestionCache struct {
+	mu      sync.Mutex
+	entries map[string]suggestionEntry
+}
+
+type suggestionEntry struct {
+	suggestions []Suggestion
+	expiresAt   time.Time
+}
+
+func (c *suggestionCache) get(query string, now time.Time) ([]Suggestion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[query]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.suggestions, true
+}
+
+func (c *suggestionCache) set(query string, suggestions []Suggestion, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]suggestionEntry)
+	}
+	if len(c.entries) >= maxAutocompleteEntries {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	// still full of fresh entries, make room by dropping any of them
+	for key := range c.entries {
+		if len(c.entries) < maxAutocompleteEntries {
+			break
+		}
+		delete(c.entries, key)
+	}
+	c.entries[query] = suggestionEntry{suggestions: suggestions, expiresAt: now.Add(autocompleteCacheTTL)}
+}
+
+// Autocomplete suggests tracks matching what the player typed so far, using Spotify's
+// search. Identical queries are served from a cache, and concurrent ones share a
+// single search. The suggestions are the same whatever quiz is being played.
+//
+// Parameters:
+//   - query: The text typed by the player.
+//
+// Returns:
+//   - A slice of Suggestion objects, in the order of the search results.
+//   - An error if the query is too short or the search fails.
+func (s *service) Autocomplete(ctx context.Context, query string) ([]Suggestion, error) {
+	query = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if len([]rune(query)) < minAutocompleteLength {
+		return nil, ErrQueryTooShort
+	}
+
+	if suggestions, ok := s.suggestions.get(query, s.now()); ok {
+		return suggestions, nil
+	}
+
+	return s.autocompletes.Do(query, func() ([]Suggestion, error) {
+		results, err := s.spotifyService.Search(query, "track", spotify.WithLimit(autocompleteLimit))
+		if err != nil {
+			log.Printf("Error searching for suggestions: %v", err)
+			return nil, err
+		}
+
+		suggestions := make([]Suggestion, 0, len(results.Tracks.Items))
+		for _, track := range results.Tracks.Items {
+			suggestions = append(suggestions, mapSuggestion(track))
+		}
+		s.suggestions.set(query, suggestions, s.now())
+		return suggestions, nil
+	})
+}
+
+// mapSuggestion converts a Spotify track to a Suggestion.
+func mapSuggestion(track spotify.Track) Suggestion {
+	suggestion := Suggestion{
+		ID:   track.ID,
+		Name: track.Name,
+	}
+	if len(track.Album.Artists) > 0 {
+		suggestion.Artist = track.Album.Artists[0].Name
+	}
+	// Spotify lists the album images from the largest to the smallest
+	if len(track.Album.Images) > 0 {
+		suggestion.Image = track.Album.Images[len(track.Album.Images)-1].URL
+	}
+	return suggestion
+}
@@ -0,0 +1,165 @@
+package quiz
+
+import (
+	"backendProject/internal/spotify"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+)
+
+// Category is a themed daily quiz, with its own constraints on the picked tracks.
+type Category struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Genres   []string `json:"genres,omitempty"`    // Spotify genres the tracks are searched with
+	YearFrom int      `json:"year_from,omitempty"` // first release year allowed
+	YearTo   int      `json:"year_to,omitempty"`   // last release year allowed
+	Market   string   `json:"market,omitempty"`    // ISO 3166-1 alpha-2 country code the tracks must be available in
+
+	// popularity range of the picked tracks, set by the difficulty of the edition
+	MinPopularity int `json:"-"`
+	MaxPopularity int `json:"-"`
+}
+
+// Edition identifies one of the daily quiz series. The zero value is the
+// general daily quiz, without a category, on normal difficulty and in classic mode.
+type Edition struct {
+	Category   string `json:"category,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+}
+
+// NewEdition returns the edition of a category on a difficulty,
+// either of them can be empty for the general quiz or normal difficulty.
+func NewEdition(category, difficulty string) Edition {
+	return Edition{Category: category, Difficulty: normalizeDifficulty(difficulty)}
+}
+
+// LoadCategories reads the quiz categories from a JSON file.
+//
+// Parameters:
+//   - path: The path of the JSON file holding an array of categories.
+//
+// Returns:
+//   - A slice of the categories in the file.
+//   - An error if the file can't be read or a category is invalid.
+func LoadCategories(path string) ([]Category, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []Category
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, err
+	}
+
+	for _, category := range categories {
+		if category.ID == "" || strings.ContainsAny(category.ID, ":/ ") {
+			return nil, fmt.Errorf("invalid category id %q", category.ID)
+		}
+		if category.YearFrom != 0 && category.YearTo != 0 && category.YearFrom > category.YearTo {
+			return nil, fmt.Errorf("category %s has an invalid year range", category.ID)
+		}
+	}
+	return categories, nil
+}
+
+// QuizID returns the ID of the quiz of the edition on the given date.
+// (2006-01-02 for the general quiz, 2006-01-02:category for a category,
+// with a :difficulty suffix when it isn't normal, e.g. 2006-01-02::hard,
+// and a mode- prefix when it isn't classic, e.g. cover-2006-01-02)
+func (e Edition) QuizID(date time.Time) string {
+	id := DailyID(date)
+	if e.Mode != "" {
+		id = e.Mode + "-" + id
+	}
+	if e.Category != "" || e.Difficulty != "" {
+		id += ":" + e.Category
+	}
+	if e.Difficulty != "" {
+		id += ":" + e.Difficulty
+	}
+	return id
+}
+
+// ParseQuizID returns the date and the edition of a daily quiz ID.
+func ParseQuizID(id string) (time.Time, Edition, error) {
+	var mode string
+	for _, m := range Modes {
+		if rest, ok := strings.CutPrefix(id, m+"-"); ok {
+			mode, id = m, rest
+			break
+		}
+	}
+
+	parts := strings.SplitN(id, ":", 3)
+	date, err := ParseDailyID(parts[0])
+	if err != nil {
+		return time.Time{}, Edition{}, err
+	}
+
+	var category, difficulty string
+	if len(parts) > 1 {
+		category = parts[1]
+	}
+	if len(parts) > 2 {
+		difficulty = parts[2]
+	}
+	return date, NewEdition(category, difficulty).WithMode(mode), nil
+}
+
+// searchQuery adds the category filters to a search query.
+func (c Category) searchQuery(r *rand.Rand, query string) string {
+	if len(c.Genres) > 0 {
+		query += fmt.Sprintf(" genre:%q", c.Genres[r.IntN(len(c.Genres))])
+	}
+	if c.YearFrom != 0 || c.YearTo != 0 {
+		query += fmt.Sprintf(" year:%d-%d", c.YearFrom, c.yearTo())
+	}
+	return query
+}
+
+// seedGenres returns up to max genres of the category to seed recommendations.
+func (c Category) seedGenres(r *rand.Rand, max int) []string {
+	genres := []string{}
+	for _, i := range r.Perm(len(c.Genres)) {
+		if len(genres) == max {
+			break
+		}
+		genres = append(genres, c.Genres[i])
+	}
+	return genres
+}
+
+// allows tells whether a track was released in the years of the category.
+func (c Category) allows(track spotify.Track) bool {
+	if c.YearFrom == 0 && c.YearTo == 0 {
+		return true
+	}
+
+	year := releaseYear(track.Album.ReleaseDate)
+	return year >= fmt.Sprintf("%04d", c.YearFrom) && year <= fmt.Sprintf("%04d", c.yearTo())
+}
+
+// popular tells whether the popularity of a track is in the range of the category.
+func (c Category) popular(track spotify.Track) bool {
+	if track.Popularity < c.MinPopularity {
+		return false
+	}
+	return c.MaxPopularity == 0 || track.Popularity <= c.MaxPopularity
+}
+
+func (c Category) yearTo() int {
+	if c.YearTo == 0 {
+		return 9999
+	}
+	return c.YearTo
+}
+
+func (c Category) options() []spotify.RequestOption {
+	return []spotify.RequestOption{spotify.WithMarket(c.Market)}
+}
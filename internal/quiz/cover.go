@@ -0,0 +1,248 @@
+package quiz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // Spotify serves album covers as JPEG
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxCoverSize is the largest cover downloaded, Spotify covers being at most 640x640.
+const maxCoverSize = 5 << 20
+
+// coverCells is the number of blocks across the pixelated cover at each hint level,
+// from a few blurry squares before the first guess to a recognizable cover on the last hint.
+var coverCells = []int{
+	HintNone:         6,
+	HintGenres:       10,
+	HintReleaseYear:  16,
+	HintAlbumImage:   26,
+	HintArtists:      40,
+	HintAudioPreview: 64,
+}
+
+// coverImage is the original album cover of a quiz in cover mode, downloaded once
+// when the quiz is generated so its URL is never sent to the players.
+type coverImage struct {
+	ContentType string    `json:"content_type"`
+	Data        []byte    `json:"data"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Cover is the album cover of a quiz, as shown to a player.
+type Cover struct {
+	Level       int // pixelation level, from 1, or 0 for the original cover
+	ContentType string
+	Data        []byte
+}
+
+// GetCover returns the album cover of a quiz, pixelated at a level unlocked by the session.
+// In cover mode the cover is the puzzle, while in classic mode it's a hint unlocked like the
+// others, sharpened by the next wrong guesses. The original cover is only returned once the
+// session is finished.
+//
+// Parameters:
+//   - quizID: The ID of the quiz being played.
+//   - sessionID: The ID of the player's session.
+//   - level: The pixelation level, from 1 to the level unlocked, or 0 for the
+//     latest level unlocked, which is the original cover once the session is finished.
+//
+// Returns:
+//   - A Cover object containing the encoded image.
+//   - An error if the quiz has no cover hint, the level is locked or the cover can't be read.
+func (s *service) GetCover(ctx context.Context, quizID, sessionID string, level int) (Cover, error) {
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return Cover{}, err
+	}
+	if mode := quiz.Edition().mode(); mode != ModeCover && mode != ModeClassic {
+		return Cover{}, ErrCoverNotFound
+	}
+
+	session, err := s.getSession(ctx, sessionID, quiz)
+	if err != nil {
+		return Cover{}, err
+	}
+	if !session.Finished() && !quiz.coverUnlocked(session) {
+		return Cover{}, ErrCoverLocked
+	}
+	if level == 0 && !session.Finished() {
+		level = session.HintLevel() + 1
+	}
+	if level < 0 || level > session.HintLevel()+1 {
+		return Cover{}, ErrCoverLocked
+	}
+
+	original, err := s.repository.GetCover(ctx, quiz.ID)
+	if err != nil {
+		log.Printf("Error getting cover of quiz %s: %v", quiz.ID, err)
+		return Cover{}, err
+	}
+	if original.CreatedAt.IsZero() && quiz.Mode != ModeCover {
+		// the cover hint of the other modes is only downloaded once a player unlocks it
+		original, err = s.cacheCover(ctx, quiz)
+		if err != nil {
+			return Cover{}, err
+		}
+	}
+	if original.CreatedAt.IsZero() {
+		return Cover{}, ErrCoverNotFound
+	}
+	if level == 0 {
+		return Cover{ContentType: original.ContentType, Data: original.Data}, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(original.Data))
+	if err != nil {
+		log.Printf("Error decoding cover of quiz %s: %v", quiz.ID, err)
+		return Cover{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, pixelate(img, coverCells[level-1])); err != nil {
+		return Cover{}, err
+	}
+	return Cover{Level: level, ContentType: "image/png", Data: buf.Bytes()}, nil
+}
+
+// storeCover downloads the album cover of a quiz in cover mode and stores it along with the quiz.
+//
+// Parameters:
+//   - quiz: The quiz in cover mode.
+//   - ttl: How long the cover is kept, 0 to keep it forever.
+//
+// Returns:
+//   - An error if the cover can't be downloaded or stored.
+func (s *service) storeCover(ctx context.Context, quiz Quiz, ttl time.Duration) error {
+	cover, err := s.downloadCover(ctx, quiz.Album.Image)
+	if err != nil {
+		log.Printf("Error downloading cover of quiz %s: %v", quiz.ID, err)
+		return err
+	}
+
+	err = s.repository.SetCover(ctx, quiz.ID, cover, ttl)
+	if err != nil {
+		log.Printf("Error setting cover of quiz %s: %v", quiz.ID, err)
+		return err
+	}
+	return nil
+}
+
+// cacheCover downloads the album cover of a quiz and stores it as long as the quiz can be played.
+func (s *service) cacheCover(ctx context.Context, quiz Quiz) (coverImage, error) {
+	cover, err := s.downloadCover(ctx, quiz.Album.Image)
+	if err != nil {
+		log.Printf("Error downloading cover of quiz %s: %v", quiz.ID, err)
+		return coverImage{}, err
+	}
+
+	var ttl time.Duration
+	if quiz.ExpiresAt != nil {
+		ttl = max(quiz.ExpiresAt.Sub(s.now()), time.Second)
+	}
+	if err := s.repository.SetCover(ctx, quiz.ID, cover, ttl); err != nil {
+		log.Printf("Error setting cover of quiz %s: %v", quiz.ID, err)
+	}
+	return cover, nil
+}
+
+// coverUnlocked tells whether a session has unlocked the pixelated cover of a quiz,
+// which is the puzzle itself in cover mode and a hint in classic mode.
+func (q Quiz) coverUnlocked(session Session) bool {
+	switch q.Edition().mode() {
+	case ModeCover:
+		return true
+	case ModeClassic:
+		return session.HintLevel() >= q.unlockLevel(HintAlbumImage)
+	}
+	return false
+}
+
+// downloadCover fetches an album cover, making sure it's an image that can be pixelated.
+func (s *service) downloadCover(ctx context.Context, url string) (coverImage, error) {
+	if url == "" {
+		return coverImage{}, ErrCoverNotFound
+	}
+
+	data, err := s.download(ctx, url, maxCoverSize)
+	if err != nil {
+		return coverImage{}, err
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return coverImage{}, err
+	}
+	return coverImage{ContentType: "image/" + format, Data: data, CreatedAt: s.now()}, nil
+}
+
+// download fetches a file served by Spotify, like an album cover or an audio preview,
+// without sending anything about the player. Files larger than maxSize bytes are refused.
+func (s *service) download(ctx context.Context, url string, maxSize int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download of %s failed with status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("download of %s is larger than %d bytes", url, maxSize)
+	}
+	return data, nil
+}
+
+// pixelate averages the colors of the image in square blocks, with the given
+// number of blocks across the image, keeping the size of the image.
+func pixelate(img image.Image, cells int) *image.RGBA {
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(src.Bounds())
+	block := max((src.Bounds().Dx()+cells-1)/cells, 1)
+	for top := 0; top < src.Bounds().Dy(); top += block {
+		for left := 0; left < src.Bounds().Dx(); left += block {
+			rect := image.Rect(left, top, left+block, top+block).Intersect(src.Bounds())
+
+			var sum [4]int
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				for x := rect.Min.X; x < rect.Max.X; x++ {
+					i := src.PixOffset(x, y)
+					for c := range sum {
+						sum[c] += int(src.Pix[i+c])
+					}
+				}
+			}
+
+			n := rect.Dx() * rect.Dy()
+			var average [4]uint8
+			for c := range sum {
+				average[c] = uint8(sum[c] / n)
+			}
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				for x := rect.Min.X; x < rect.Max.X; x++ {
+					copy(dst.Pix[dst.PixOffset(x, y):], average[:])
+				}
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,375 @@
+package quiz
+
+import (
+	"backendProject/internal/spotify"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// customPrefix starts the IDs of the rounds of custom quizzes, telling them apart from the daily ones.
+	customPrefix = "custom-"
+	// maxCustomTracks is the number of tracks a custom quiz can be made of,
+	// fetched from Spotify in a single request.
+	maxCustomTracks   = 50
+	maxTitleLength    = 100
+	shareCodeLength   = 6
+	shareCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // without the look-alike characters
+	maxShareCodeTries = 5
+)
+
+var ErrInvalidCustomQuiz = errors.New("invalid custom quiz")
+
+// customModes lists the game modes a custom quiz can be played in, the ones guessing a track.
+var customModes = []string{ModeClassic, ModeCover, ModeAudio}
+
+// hintNames names the hints of the classic ladder, to order them in a custom quiz.
+var hintNames = map[string]int{
+	"genres":       HintGenres,
+	"release_year": HintReleaseYear,
+	"album_image":  HintAlbumImage,
+	"artists":      HintArtists,
+	"audio":        HintAudioPreview,
+}
+
+// CustomQuizRequest holds the settings of a custom quiz made from chosen tracks.
+type CustomQuizRequest struct {
+	Title    string   `json:"title"`
+	TrackIDs []string `json:"track_ids"`
+	Mode     string   `json:"mode,omitempty"`   // classic, cover or audio, classic by default
+	Rounds   int      `json:"rounds,omitempty"` // tracks picked at random from the list, all of them by default
+	// Hints orders the hints unlocked by each wrong guess, like ["artists", "genres"].
+	// Hints left out are never unlocked, and the classic ladder is used by default.
+	Hints []string `json:"hints,omitempty"`
+}
+
+// CustomQuiz is a quiz made by a player from chosen tracks, shared by its code.
+// Each round is a quiz played through the same APIs as the daily quiz.
+type CustomQuiz struct {
+	Code      string    `json:"code"`
+	Title     string    `json:"title"`
+	Mode      string    `json:"mode"`
+	Rounds    []string  `json:"rounds"` // IDs of the quizzes of the rounds, in order
+	Hints     []string  `json:"hints,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// isCustomID tells whether a quiz ID belongs to a round of a custom quiz.
+func isCustomID(id string) bool {
+	return strings.HasPrefix(id, customPrefix)
+}
+
+// unlockLevel returns the hint level unlocking a hint of the classic ladder, following
+// the custom ladder of the quiz when it has one, which never unlocks the hints left out.
+func (q Quiz) unlockLevel(hint int) int {
+	if q.HintLadder == nil {
+		return hint
+	}
+	i := slices.Index(q.HintLadder, hint)
+	if i == -1 {
+		return math.MaxInt
+	}
+	return i + 1
+}
+
+// CreateCustomQuiz builds a custom quiz from chosen tracks, each round being validated
+// like the generated quizzes, and stores it under a new share code. Custom quizzes never expire.
+//
+// Parameters:
+//   - req: The title, tracks and settings of the custom quiz.
+//
+// Returns:
+//   - A CustomQuiz object with the share code and the IDs of its rounds.
+//   - An ErrInvalidCustomQuiz error if the settings are invalid or a track can't be played,
+//     or an error if the requests fail.
+func (s *service) CreateCustomQuiz(ctx context.Context, req CustomQuizRequest) (CustomQuiz, error) {
+	req, ladder, err := normalizeCustomQuiz(req)
+	if err != nil {
+		return CustomQuiz{}, err
+	}
+
+	tracks, err := s.spotifyService.GetTracks(req.TrackIDs)
+	if err != nil {
+		log.Printf("Error getting tracks of custom quiz: %v", err)
+		return CustomQuiz{}, err
+	}
+	// unknown IDs are returned as empty tracks
+	chosen := make([]spotify.Track, len(req.TrackIDs))
+	for i, id := range req.TrackIDs {
+		j := slices.IndexFunc(tracks.Tracks, func(t spotify.Track) bool { return t.ID == id })
+		if j == -1 {
+			return CustomQuiz{}, fmt.Errorf("%w: track %s not found", ErrInvalidCustomQuiz, id)
+		}
+		chosen[i] = tracks.Tracks[j]
+	}
+
+	custom := CustomQuiz{Title: req.Title, Mode: req.Mode, Hints: req.Hints}
+	custom, quizzes, err := s.buildCustomRounds(ctx, custom, ladder, chosen, req.Rounds, true)
+	if err != nil {
+		return CustomQuiz{}, err
+	}
+	return s.storeCustomQuiz(ctx, custom, quizzes)
+}
+
+// buildCustomRounds picks the rounds of a custom quiz at random among tracks, each round
+// being validated like the generated quizzes, and assigns the custom quiz a new share code.
+//
+// Parameters:
+//   - custom: The title, mode and hints of the custom quiz.
+//   - ladder: The custom hint ladder, nil for the classic one.
+//   - tracks: The tracks to pick the rounds from.
+//   - rounds: The number of rounds to pick.
+//   - strict: Whether a track that can't be played rejects the quiz, rather than being skipped.
+//
+// Returns:
+//   - The CustomQuiz object with its share code and the IDs of its rounds.
+//   - The rounds, fewer than requested when too many tracks are skipped.
+//   - An ErrInvalidCustomQuiz error if a track can't be played in strict mode, or an error if the requests fail.
+func (s *service) buildCustomRounds(ctx context.Context, custom CustomQuiz, ladder []int, tracks []spotify.Track, rounds int, strict bool) (CustomQuiz, []Quiz, error) {
+	code, err := s.newShareCode(ctx)
+	if err != nil {
+		return CustomQuiz{}, nil, err
+	}
+	custom.Code = code
+	custom.CreatedAt = s.now()
+
+	// the rounds are picked and validated before anything is stored
+	r := s.randForQuiz(customPrefix + custom.Code)
+	quizzes := make([]Quiz, 0, rounds)
+	for _, n := range r.Perm(len(tracks)) {
+		if len(quizzes) == rounds {
+			break
+		}
+		track := tracks[n]
+		artists, err := s.spotifyService.GetArtists(artistIDs(track.Album.Artists, 5))
+		if err != nil {
+			log.Printf("Error getting artists of track %s: %v", track.ID, err)
+			return CustomQuiz{}, nil, err
+		}
+
+		quiz := buildQuiz(track, artists.Artists)
+		quiz.DifficultyScore = DifficultyScore(track, artists.Artists)
+		quiz.ID = customRoundID(custom.Code, len(quizzes)+1)
+		quiz.Mode = custom.Mode
+		quiz.Title = custom.Title
+		quiz.Round = len(quizzes) + 1
+		quiz.HintLadder = ladder
+		quiz.Artists = dedupeArtists(quiz.Artists)
+		if quiz.Mode == ModeAudio {
+			quiz = s.withPreview(quiz)
+		}
+		if err := s.validateQuiz(quiz); err != nil {
+			if strict {
+				return CustomQuiz{}, nil, fmt.Errorf("%w: track %s: %w", ErrInvalidCustomQuiz, track.ID, err)
+			}
+			log.Printf("Skipping track %s of custom quiz %s: %v", track.ID, custom.Code, err)
+			continue
+		}
+		quizzes = append(quizzes, quiz)
+		custom.Rounds = append(custom.Rounds, quiz.ID)
+	}
+	return custom, quizzes, nil
+}
+
+// storeCustomQuiz stores a custom quiz along with its rounds, their covers and previews.
+// The share code is claimed first, the custom quiz and its rounds being given a new code
+// when another custom quiz was stored with the same code since it was drawn.
+//
+// Parameters:
+//   - custom: The custom quiz with its share code and the IDs of its rounds.
+//   - quizzes: The rounds of the custom quiz.
+//
+// Returns:
+//   - The stored CustomQuiz object.
+//   - An error if a round or the custom quiz can't be stored.
+func (s *service) storeCustomQuiz(ctx context.Context, custom CustomQuiz, quizzes []Quiz) (CustomQuiz, error) {
+	for tries := 1; ; tries++ {
+		stored, err := s.repository.AddCustomQuiz(ctx, custom)
+		if err != nil {
+			log.Printf("Error setting custom quiz %s: %v", custom.Code, err)
+			return CustomQuiz{}, err
+		}
+		if stored {
+			break
+		}
+		if tries == maxShareCodeTries {
+			return CustomQuiz{}, errors.New("no free share code found")
+		}
+
+		log.Printf("Share code %s was taken by another custom quiz, drawing another one", custom.Code)
+		code, err := s.newShareCode(ctx)
+		if err != nil {
+			return CustomQuiz{}, err
+		}
+		custom.Code = code
+		for i := range quizzes {
+			quizzes[i].ID = customRoundID(code, quizzes[i].Round)
+			custom.Rounds[i] = quizzes[i].ID
+		}
+	}
+
+	for _, quiz := range quizzes {
+		if quiz.Mode == ModeCover {
+			if err := s.storeCover(ctx, quiz, 0); err != nil {
+				return CustomQuiz{}, err
+			}
+		}
+		if err := s.repository.SetCustomRound(ctx, quiz); err != nil {
+			log.Printf("Error setting round %s: %v", quiz.ID, err)
+			return CustomQuiz{}, err
+		}
+		if quiz.preview != nil {
+			s.cachePreview(ctx, quiz, quiz.preview)
+		}
+	}
+	log.Printf("Created custom quiz %s with %d rounds", custom.Code, len(custom.Rounds))
+	return custom, nil
+}
+
+// normalizeCustomQuiz checks the settings of a custom quiz and fills in the defaults.
+//
+// Parameters:
+//   - req: The settings of the custom quiz.
+//
+// Returns:
+//   - The settings with a trimmed title, deduplicated track IDs and the number of rounds and mode set.
+//   - The custom hint ladder, nil for the classic one.
+//   - An ErrInvalidCustomQuiz error if a setting is invalid.
+func normalizeCustomQuiz(req CustomQuizRequest) (CustomQuizRequest, []int, error) {
+	title, err := checkTitle(req.Title)
+	if err != nil {
+		return req, nil, err
+	}
+	req.Title = title
+
+	ids := []string{}
+	for _, id := range req.TrackIDs {
+		if id = strings.TrimSpace(id); id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	req.TrackIDs = ids
+	if len(ids) == 0 || len(ids) > maxCustomTracks {
+		return req, nil, fmt.Errorf("%w: expected 1 to %d tracks", ErrInvalidCustomQuiz, maxCustomTracks)
+	}
+	if req.Rounds == 0 {
+		req.Rounds = len(ids)
+	}
+	if req.Rounds < 0 || req.Rounds > len(ids) {
+		return req, nil, fmt.Errorf("%w: expected 1 to %d rounds", ErrInvalidCustomQuiz, len(ids))
+	}
+
+	mode, ladder, err := parseHintLadder(req.Mode, req.Hints)
+	if err != nil {
+		return req, nil, err
+	}
+	req.Mode = mode
+	return req, ladder, nil
+}
+
+// checkTitle trims the title of a custom quiz and checks its length.
+func checkTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || len([]rune(title)) > maxTitleLength {
+		return title, fmt.Errorf("%w: title must have 1 to %d characters", ErrInvalidCustomQuiz, maxTitleLength)
+	}
+	return title, nil
+}
+
+// parseHintLadder checks the mode of a custom quiz and the order of its hints.
+//
+// Parameters:
+//   - mode: The game mode, classic when empty.
+//   - hints: The names of the hints in the order they are unlocked.
+//
+// Returns:
+//   - The game mode.
+//   - The custom hint ladder, nil for the classic one.
+//   - An ErrInvalidCustomQuiz error if the mode or a hint is invalid.
+func parseHintLadder(mode string, hints []string) (string, []int, error) {
+	if mode == "" {
+		mode = ModeClassic
+	}
+	if !slices.Contains(customModes, mode) {
+		return mode, nil, fmt.Errorf("%w: mode must be one of %s", ErrInvalidCustomQuiz, strings.Join(customModes, ", "))
+	}
+
+	var ladder []int
+	for _, name := range hints {
+		hint, ok := hintNames[name]
+		if !ok || slices.Contains(ladder, hint) {
+			return mode, nil, fmt.Errorf("%w: unknown or repeated hint %q", ErrInvalidCustomQuiz, name)
+		}
+		ladder = append(ladder, hint)
+	}
+	if ladder != nil && mode == ModeAudio {
+		return mode, nil, fmt.Errorf("%w: audio mode has no hints", ErrInvalidCustomQuiz)
+	}
+	return mode, ladder, nil
+}
+
+// newShareCode returns a short code, not used by any custom quiz yet. The code is
+// only claimed once the custom quiz is stored. (see storeCustomQuiz)
+func (s *service) newShareCode(ctx context.Context) (string, error) {
+	for tries := 0; tries < maxShareCodeTries; tries++ {
+		code := make([]byte, shareCodeLength)
+		for i := range code {
+			code[i] = shareCodeAlphabet[rand.IntN(len(shareCodeAlphabet))]
+		}
+
+		existing, err := s.repository.GetCustomQuiz(ctx, string(code))
+		if err != nil {
+			log.Printf("Error getting custom quiz %s: %v", code, err)
+			return "", err
+		}
+		if existing.CreatedAt.IsZero() {
+			return string(code), nil
+		}
+	}
+	return "", errors.New("no free share code found")
+}
+
+// customRoundID returns the ID of a round of a custom quiz, numbered from 1.
+func customRoundID(code string, round int) string {
+	return fmt.Sprintf("%s%s-%d", customPrefix, code, round)
+}
+
+// GetCustomQuiz returns the custom quiz shared with the given code.
+//
+// Parameters:
+//   - code: The share code of the custom quiz.
+//
+// Returns:
+//   - A CustomQuiz object with the IDs of its rounds.
+//   - An error if the custom quiz is not found or can't be read.
+func (s *service) GetCustomQuiz(ctx context.Context, code string) (CustomQuiz, error) {
+	custom, err := s.repository.GetCustomQuiz(ctx, code)
+	if err != nil {
+		log.Printf("Error getting custom quiz %s: %v", code, err)
+		return CustomQuiz{}, err
+	}
+	if custom.CreatedAt.IsZero() {
+		return CustomQuiz{}, ErrQuizNotFound
+	}
+	return custom, nil
+}
+
+// getCustomRound returns the round of a custom quiz with the given ID.
+func (s *service) getCustomRound(ctx context.Context, id string) (Quiz, error) {
+	quiz, err := s.repository.GetCustomRound(ctx, id)
+	if err != nil {
+		log.Printf("Error getting round %s: %v", id, err)
+		return Quiz{}, err
+	}
+	if quiz.CreatedAt.IsZero() {
+		return Quiz{}, ErrQuizNotFound
+	}
+	return quiz, nil
+}
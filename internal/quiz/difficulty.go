@@ -0,0 +1,123 @@
+package quiz
+
+import (
+	"backendProject/internal/spotify"
+	"math"
+)
+
+// Difficulty levels of the daily quiz. The normal difficulty is the zero value
+// of Edition.Difficulty, so normal quizzes keep their plain date IDs.
+const (
+	DifficultyEasy   = "easy"
+	DifficultyNormal = "normal"
+	DifficultyHard   = "hard"
+)
+
+// Difficulties lists the difficulty levels, from the easiest to the hardest.
+var Difficulties = []string{DifficultyEasy, DifficultyNormal, DifficultyHard}
+
+const (
+	maxDifficultyAttempts = 5
+	maxFollowers          = 100_000_000 // followers of the most famous artists, scored as fully known
+)
+
+// difficultyLevel holds the popularity range the tracks of a difficulty are
+// picked from, and the range of difficulty scores its quizzes must have.
+type difficultyLevel struct {
+	minPopularity int
+	maxPopularity int
+	minScore      int
+	maxScore      int
+}
+
+// difficultyLevels holds the ranges of each difficulty. The normal difficulty is the default
+// edition, whose tracks are picked with a popularity of 80 or more as they always were.
+var difficultyLevels = map[string]difficultyLevel{
+	DifficultyEasy:   {minPopularity: 90, maxPopularity: 100, minScore: 0, maxScore: 24},
+	DifficultyNormal: {minPopularity: 80, maxPopularity: 100, minScore: 0, maxScore: 100},
+	DifficultyHard:   {minPopularity: 30, maxPopularity: 60, minScore: 45, maxScore: 100},
+}
+
+// distance returns how far a difficulty score is from the range of the level.
+func (l difficultyLevel) distance(score int) int {
+	switch {
+	case score < l.minScore:
+		return l.minScore - score
+	case score > l.maxScore:
+		return score - l.maxScore
+	default:
+		return 0
+	}
+}
+
+// DifficultyScore rates how hard a track is to guess, from 0 for a hit everybody
+// knows to 100 for an obscure song. It weighs the popularity of the track with the
+// follower count of its most followed artist, on a logarithmic scale.
+//
+// Parameters:
+//   - track: The Spotify track of the quiz.
+//   - artists: The Spotify artists of the track.
+//
+// Returns:
+//   - The difficulty score of the track, between 0 and 100.
+func DifficultyScore(track spotify.Track, artists []spotify.Artist) int {
+	followers := 0
+	for _, artist := range artists {
+		followers = max(followers, artist.Followers.Total)
+	}
+	return difficultyScore(track.Popularity, followers)
+}
+
+// ArtistDifficultyScore rates how hard an artist is to guess in artist mode, like
+// DifficultyScore does for tracks, from the popularity and follower count of the artist.
+//
+// Parameters:
+//   - artist: The Spotify artist of the quiz.
+//
+// Returns:
+//   - The difficulty score of the artist, between 0 and 100.
+func ArtistDifficultyScore(artist spotify.Artist) int {
+	return difficultyScore(artist.Popularity, artist.Followers.Total)
+}
+
+// AlbumDifficultyScore rates how hard an album is to guess in album mode, like
+// DifficultyScore does for tracks, from the popularity of the album and the
+// follower count of its most followed artist.
+//
+// Parameters:
+//   - album: The full Spotify album of the quiz.
+//   - artists: The Spotify artists of the album.
+//
+// Returns:
+//   - The difficulty score of the album, between 0 and 100.
+func AlbumDifficultyScore(album spotify.Album, artists []spotify.Artist) int {
+	followers := 0
+	for _, artist := range artists {
+		followers = max(followers, artist.Followers.Total)
+	}
+	return difficultyScore(album.Popularity, followers)
+}
+
+// difficultyScore weighs a popularity from 0 to 100 with a follower count, on a logarithmic scale.
+func difficultyScore(popularity, followers int) int {
+	followerScore := min(math.Log10(float64(followers)+1)/math.Log10(maxFollowers), 1) * 100
+	fame := 0.6*float64(popularity) + 0.4*followerScore
+	return int(math.Round(100 - fame))
+}
+
+// normalizeDifficulty returns the difficulty as stored in an edition,
+// where the normal difficulty is left empty.
+func normalizeDifficulty(difficulty string) string {
+	if difficulty == DifficultyNormal {
+		return ""
+	}
+	return difficulty
+}
+
+// difficulty returns the difficulty level name of the edition.
+func (e Edition) difficulty() string {
+	if e.Difficulty == "" {
+		return DifficultyNormal
+	}
+	return e.Difficulty
+}
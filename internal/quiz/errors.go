@@ -0,0 +1,29 @@
+package quiz
+
+import "errors"
+
+var (
+	ErrEmptyGuess      = errors.New("guess must have a track id or a track name, or an artist or album id or name in the artist and album modes")
+	ErrTrackNotFound   = errors.New("guessed track not found")
+	ErrArtistNotFound  = errors.New("guessed artist not found")
+	ErrAlbumNotFound   = errors.New("guessed album not found")
+	ErrQuizSolved      = errors.New("quiz already finished in this session")
+	ErrQueryTooShort   = errors.New("autocomplete query must have at least 2 characters")
+	ErrQuizNotFinished = errors.New("quiz must be solved or given up before sharing the results")
+	ErrCoverLocked     = errors.New("cover level not unlocked yet")
+	ErrAudioLocked     = errors.New("audio clip not unlocked yet")
+	ErrSkipNotAllowed  = errors.New("only quizzes in audio mode can be skipped")
+	ErrInvalidPlaylist = errors.New("invalid playlist, expected a Spotify playlist link or ID")
+	ErrInvalidRounds   = errors.New("rounds must be between 1 and 20")
+	ErrQuizSessionOver = errors.New("every round of the quiz session was already played")
+
+	ErrInvalidQuizID      = errors.New("invalid quiz id, expected a date formatted as YYYY-MM-DD")
+	ErrCategoryNotFound   = errors.New("quiz category not found")
+	ErrDifficultyNotFound = errors.New("quiz difficulty not found, expected easy, normal or hard")
+	ErrModeNotFound       = errors.New("quiz mode not found or not enabled")
+	ErrCoverNotFound      = errors.New("quiz has no cover to guess")
+	ErrAudioNotFound      = errors.New("quiz has no audio preview")
+	ErrQuizNotFound       = errors.New("quiz not found")
+	ErrQuizNotRevealed    = errors.New("quiz answer is revealed only after the day is over")
+	ErrPlaylistNotFound   = errors.New("playlist not found or not public")
+)
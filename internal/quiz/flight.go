@@ -0,0 +1,52 @@
+package quiz
+
+import "sync"
+
+// flightCall is an in progress call of a flightGroup.
+type flightCall[T any] struct {
+	wg  sync.WaitGroup
+	val T
+	err error
+}
+
+// flightGroup deduplicates concurrent calls sharing the same key, so only
+// one of them runs while the others wait for and share its result.
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[T]
+}
+
+// Do runs fn once for every concurrent caller of the given key.
+//
+// Parameters:
+//   - key: The key identifying the call.
+//   - fn: The function to run.
+//
+// Returns:
+//   - The value returned by fn.
+//   - The error returned by fn.
+func (g *flightGroup[T]) Do(key string, fn func() (T, error)) (T, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[T])
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.val, call.err
+	}
+
+	call := &flightCall[T]{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.val, call.err = fn()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.val, call.err
+}
@@ -0,0 +1,83 @@
+package quiz
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Size of the glyphs of the bitmap font, in font pixels.
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphSpacing = 1
+)
+
+// glyphs is a tiny bitmap font to write on the share card without
+// depending on font files. Runes without a glyph are left blank.
+var glyphs = map[rune][glyphHeight]string{
+	'A': {" ### ", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'B': {"#### ", "#   #", "#   #", "#### ", "#   #", "#   #", "#### "},
+	'C': {" ### ", "#   #", "#    ", "#    ", "#    ", "#   #", " ### "},
+	'D': {"#### ", "#   #", "#   #", "#   #", "#   #", "#   #", "#### "},
+	'E': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#####"},
+	'F': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#    "},
+	'G': {" ### ", "#   #", "#    ", "# ###", "#   #", "#   #", " ####"},
+	'H': {"#   #", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'I': {" ### ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'J': {"  ###", "   # ", "   # ", "   # ", "   # ", "#  # ", " ##  "},
+	'K': {"#   #", "#  # ", "# #  ", "##   ", "# #  ", "#  # ", "#   #"},
+	'L': {"#    ", "#    ", "#    ", "#    ", "#    ", "#    ", "#####"},
+	'M': {"#   #", "## ##", "# # #", "# # #", "#   #", "#   #", "#   #"},
+	'N': {"#   #", "#   #", "##  #", "# # #", "#  ##", "#   #", "#   #"},
+	'O': {" ### ", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'P': {"#### ", "#   #", "#   #", "#### ", "#    ", "#    ", "#    "},
+	'Q': {" ### ", "#   #", "#   #", "#   #", "# # #", "#  # ", " ## #"},
+	'R': {"#### ", "#   #", "#   #", "#### ", "# #  ", "#  # ", "#   #"},
+	'S': {" ####", "#    ", "#    ", " ### ", "    #", "    #", "#### "},
+	'T': {"#####", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'U': {"#   #", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'V': {"#   #", "#   #", "#   #", "#   #", "#   #", " # # ", "  #  "},
+	'W': {"#   #", "#   #", "#   #", "# # #", "# # #", "# # #", " # # "},
+	'X': {"#   #", "#   #", " # # ", "  #  ", " # # ", "#   #", "#   #"},
+	'Y': {"#   #", "#   #", " # # ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'Z': {"#####", "    #", "   # ", "  #  ", " #   ", "#    ", "#####"},
+	'0': {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+	'#': {" # # ", " # # ", "#####", " # # ", "#####", " # # ", " # # "},
+}
+
+// drawText writes a line of text with the bitmap font, each font pixel
+// being drawn as a square of scale pixels.
+//
+// Parameters:
+//   - img: The image to draw on.
+//   - text: The text to write, in uppercase.
+//   - x, y: The top left corner of the text.
+//   - scale: The size of each font pixel.
+//   - c: The color of the text.
+func drawText(img *image.RGBA, text string, x, y, scale int, c color.Color) {
+	fill := image.NewUniform(c)
+	for _, r := range text {
+		glyph := glyphs[r]
+		for row, line := range glyph {
+			for col, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+				px := x + col*scale
+				py := y + row*scale
+				draw.Draw(img, image.Rect(px, py, px+scale, py+scale), fill, image.Point{}, draw.Src)
+			}
+		}
+		x += (glyphWidth + glyphSpacing) * scale
+	}
+}
@@ -1,8 +1,43 @@
 package quiz
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"image/png"
+	"log"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// playerHeader and playerCookie carry the anonymous ID of the player, which also
+	// identifies their session on each quiz. Requests without it get a new ID, sent
+	// back in both the header and the cookie.
+	playerHeader    = "Player-ID"
+	playerCookie    = "player_id"
+	playerCookieAge = 400 * 24 * 60 * 60 // longest lifetime allowed by browsers, in seconds
+	// sessionHeader is the former name of the player header, still accepted.
+	sessionHeader = "Session-ID"
+
+	// autocompleteCacheControl lets clients and proxies reuse the suggestions of a query
+	autocompleteCacheControl = "public, max-age=600"
+	// coverCacheControl lets the player's browser keep a pixelated cover, which never
+	// changes once its quiz ID and level are in the URL, but not the original one
+	coverCacheControl = "private, max-age=86400"
+	// audioCacheControl lets the player's browser keep an audio clip, which never
+	// changes once its quiz ID and length are in the URL
+	audioCacheControl = "private, max-age=86400"
+	// noStoreCacheControl is sent for the URLs of today's quiz or of the latest unlocked
+	// cover or clip, whose content changes with the day or the player's progress
+	noStoreCacheControl = "no-store"
+
+	defaultPageLimit = 10
+	maxPageLimit     = 50
 )
 
 type Handler struct {
@@ -15,17 +50,612 @@ func NewHandler(s Service) *Handler {
 	}
 }
 
-// GetTodaysQuizHandler returns today's quiz.
+// GetTodaysQuizHandler returns the clues of today's quiz unlocked by the session.
+// The optional category query parameter selects a themed quiz.
 //
 // Returns:
-//   - A JSON object containing the quiz data.
+//   - A JSON object containing the unlocked clues, without the answer.
 func (h *Handler) GetTodaysQuizHandler(w http.ResponseWriter, r *http.Request) {
-	quiz, err := h.Service.GetTodaysQuiz(r.Context())
+	clues, err := h.Service.GetClues(r.Context(), h.Service.TodaysQuizID(edition(r)), playerID(w, r))
+	if err != nil {
+		writeError(w, err, "Error getting today's quiz")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clues)
+}
+
+// GetQuizHandler returns the clues of the quiz from the date in the URL,
+// so past quizzes can be replayed, or of the practice quiz with the ID in the URL. The optional category query parameter
+// selects a themed quiz.
+//
+// Returns:
+//   - A JSON object containing the unlocked clues, without the answer.
+func (h *Handler) GetQuizHandler(w http.ResponseWriter, r *http.Request) {
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error getting quiz")
+		return
+	}
+
+	clues, err := h.Service.GetClues(r.Context(), quizID, playerID(w, r))
+	if err != nil {
+		writeError(w, err, "Error getting quiz")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clues)
+}
+
+// GetAnswerHandler returns the answer of a past quiz.
+//
+// Returns:
+//   - A JSON object containing the whole quiz data.
+func (h *Handler) GetAnswerHandler(w http.ResponseWriter, r *http.Request) {
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error getting quiz answer")
+		return
+	}
+
+	quiz, err := h.Service.GetAnswer(r.Context(), quizID)
+	if err != nil {
+		writeError(w, err, "Error getting quiz answer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quiz)
+}
+
+// ListQuizzesHandler returns a page of the quiz archive.
+// The page and limit query parameters are optional.
+//
+// Returns:
+//   - A JSON array containing the quizzes in the page.
+func (h *Handler) ListQuizzesHandler(w http.ResponseWriter, r *http.Request) {
+	page, err := queryInt(r, "page", 1)
+	if err != nil || page < 1 {
+		http.Error(w, "invalid page parameter", http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(r, "limit", defaultPageLimit)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		http.Error(w, "invalid limit parameter", http.StatusBadRequest)
+		return
+	}
+
+	quizzes, err := h.Service.ListQuizzes(r.Context(), edition(r), page, limit)
+	if err != nil {
+		writeError(w, err, "Error listing quizzes")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quizzes)
+}
+
+// GuessHandler checks a guess against today's quiz, or against the quiz
+// from the date or the practice quiz ID in the URL when there is one.
+//
+// Returns:
+//   - A JSON object containing the feedback for each attribute of the guess and the unlocked clues.
+func (h *Handler) GuessHandler(w http.ResponseWriter, r *http.Request) {
+	var guess Guess
+	if err := json.NewDecoder(r.Body).Decode(&guess); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error checking guess")
+		return
+	}
+
+	result, err := h.Service.Guess(r.Context(), quizID, playerID(w, r), guess)
+	if err != nil {
+		writeError(w, err, "Error checking guess")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// SkipHandler skips a try on today's quiz in audio mode, or on the quiz from the date
+// or the practice quiz ID in the URL, unlocking the next clip.
+//
+// Returns:
+//   - A JSON object marking the skip, with the unlocked clues.
+func (h *Handler) SkipHandler(w http.ResponseWriter, r *http.Request) {
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error skipping quiz")
+		return
+	}
+
+	result, err := h.Service.Skip(r.Context(), quizID, playerID(w, r))
 	if err != nil {
-		http.Error(w, "Error getting today's quiz", http.StatusInternalServerError)
+		writeError(w, err, "Error skipping quiz")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GiveUpHandler ends the session on today's quiz, or on the quiz from the date
+// or the practice quiz ID in the URL, revealing the answer.
+//
+// Returns:
+//   - A JSON object containing the whole quiz data.
+func (h *Handler) GiveUpHandler(w http.ResponseWriter, r *http.Request) {
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error giving up quiz")
+		return
+	}
+
+	quiz, err := h.Service.GiveUp(r.Context(), quizID, playerID(w, r))
+	if err != nil {
+		writeError(w, err, "Error giving up quiz")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(quiz)
 }
+
+// CoverHandler returns the pixelated album cover of today's quiz,
+// or of the quiz from the date or the practice quiz ID in the URL. The optional level
+// query parameter selects an unlocked pixelation level, defaulting to the latest one
+// or to the original cover once the quiz is finished. Only the covers of a quiz ID
+// and level in the URL are cached, the others changing with the player's progress.
+//
+// Returns:
+//   - The cover image.
+func (h *Handler) CoverHandler(w http.ResponseWriter, r *http.Request) {
+	level, err := queryInt(r, "level", 0)
+	if err != nil || level < 0 || level > len(coverCells) {
+		http.Error(w, "invalid level parameter", http.StatusBadRequest)
+		return
+	}
+
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error getting cover")
+		return
+	}
+
+	cover, err := h.Service.GetCover(r.Context(), quizID, playerID(w, r), level)
+	if err != nil {
+		writeError(w, err, "Error getting cover")
+		return
+	}
+
+	w.Header().Set("Content-Type", cover.ContentType)
+	if chi.URLParam(r, "id") != "" && level != 0 && cover.Level != 0 {
+		w.Header().Set("Cache-Control", coverCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", noStoreCacheControl)
+	}
+	w.Write(cover.Data)
+}
+
+// AudioHandler plays the first seconds of the audio preview of today's quiz, or of
+// the quiz from the date or the practice quiz ID in the URL. The optional seconds query
+// parameter sets the length of the clip, defaulting to the longest clip unlocked. The
+// preview is proxied so its URL, which identifies the track, never reaches the player.
+// Only the clips of a quiz ID and length in the URL are cached.
+//
+// Returns:
+//   - The MP3 clip.
+func (h *Handler) AudioHandler(w http.ResponseWriter, r *http.Request) {
+	seconds, err := queryInt(r, "seconds", 0)
+	if err != nil || seconds < 0 || seconds > previewSeconds {
+		http.Error(w, "invalid seconds parameter", http.StatusBadRequest)
+		return
+	}
+
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error getting audio")
+		return
+	}
+
+	audio, err := h.Service.GetAudio(r.Context(), quizID, playerID(w, r), seconds)
+	if err != nil {
+		writeError(w, err, "Error getting audio")
+		return
+	}
+
+	w.Header().Set("Content-Type", audio.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(audio.Data)))
+	if chi.URLParam(r, "id") != "" && seconds != 0 {
+		w.Header().Set("Cache-Control", audioCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", noStoreCacheControl)
+	}
+	w.Write(audio.Data)
+}
+
+// PracticeHandler generates a practice quiz for the session, to keep playing after
+// the daily quiz. The optional category and difficulty query parameters select
+// the kind of quiz, and guesses are sent with the returned quiz ID.
+//
+// Returns:
+//   - A JSON object containing the clues of the new quiz, along with its ID.
+func (h *Handler) PracticeHandler(w http.ResponseWriter, r *http.Request) {
+	clues, err := h.Service.NewPracticeQuiz(r.Context(), edition(r), playerID(w, r))
+	if err != nil {
+		writeError(w, err, "Error generating practice quiz")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(clues)
+}
+
+// CreateQuizSessionHandler starts a quiz session of several scored rounds for the player.
+// The optional category, difficulty and mode query parameters select the kind of rounds,
+// and the optional rounds query parameter their number.
+//
+// Returns:
+//   - A JSON object containing the quiz session, whose rounds are served by NextRoundHandler.
+func (h *Handler) CreateQuizSessionHandler(w http.ResponseWriter, r *http.Request) {
+	rounds, err := queryInt(r, "rounds", 0)
+	if err != nil {
+		http.Error(w, "invalid rounds parameter", http.StatusBadRequest)
+		return
+	}
+
+	quizSession, err := h.Service.NewQuizSession(r.Context(), edition(r), playerID(w, r), rounds)
+	if err != nil {
+		writeError(w, err, "Error creating quiz session")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(quizSession)
+}
+
+// NextRoundHandler serves the next round of the quiz session with the ID in the URL,
+// or the current one while it isn't finished. Guesses are sent with the returned quiz ID.
+//
+// Returns:
+//   - A JSON object containing the clues of the round, along with the ID of its quiz.
+func (h *Handler) NextRoundHandler(w http.ResponseWriter, r *http.Request) {
+	clues, err := h.Service.NextRound(r.Context(), chi.URLParam(r, "id"), playerID(w, r))
+	if err != nil {
+		writeError(w, err, "Error serving next round")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clues)
+}
+
+// GetQuizSessionHandler returns the quiz session with the ID in the URL, along with
+// the points of each round, making up the final breakdown once the session is finished.
+//
+// Returns:
+//   - A JSON object containing the quiz session, its rounds and its score.
+func (h *Handler) GetQuizSessionHandler(w http.ResponseWriter, r *http.Request) {
+	quizSession, err := h.Service.GetQuizSession(r.Context(), chi.URLParam(r, "id"), playerID(w, r))
+	if err != nil {
+		writeError(w, err, "Error getting quiz session")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quizSession)
+}
+
+// CreateCustomQuizHandler creates a custom quiz from the chosen tracks in the request body,
+// whose rounds are played with their IDs like the daily quiz.
+//
+// Returns:
+//   - A JSON object containing the custom quiz, along with its share code and the IDs of its rounds.
+func (h *Handler) CreateCustomQuizHandler(w http.ResponseWriter, r *http.Request) {
+	var req CustomQuizRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	custom, err := h.Service.CreateCustomQuiz(r.Context(), req)
+	if err != nil {
+		writeError(w, err, "Error creating custom quiz")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(custom)
+}
+
+// PlaylistQuizHandler creates a custom quiz from the tracks of the public playlist in the
+// request body, whose rounds are played with their IDs like the daily quiz.
+//
+// Returns:
+//   - A JSON object containing the custom quiz, along with its share code and the IDs of its rounds.
+func (h *Handler) PlaylistQuizHandler(w http.ResponseWriter, r *http.Request) {
+	var req PlaylistQuizRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	custom, err := h.Service.NewPlaylistQuiz(r.Context(), req)
+	if err != nil {
+		writeError(w, err, "Error creating playlist quiz")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(custom)
+}
+
+// GetCustomQuizHandler returns the custom quiz shared with the code in the URL.
+//
+// Returns:
+//   - A JSON object containing the custom quiz, along with the IDs of its rounds.
+func (h *Handler) GetCustomQuizHandler(w http.ResponseWriter, r *http.Request) {
+	custom, err := h.Service.GetCustomQuiz(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		writeError(w, err, "Error getting custom quiz")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(custom)
+}
+
+// AutocompleteHandler suggests tracks to guess, matching the q query parameter.
+//
+// Returns:
+//   - A JSON array containing the suggested tracks.
+func (h *Handler) AutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := h.Service.Autocomplete(r.Context(), r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, err, "Error getting suggestions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", autocompleteCacheControl)
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+// ShareHandler returns the spoiler free results of the session on today's quiz,
+// or on the quiz from the date or the practice quiz ID in the URL, once solved.
+//
+// Returns:
+//   - A JSON object containing the share text and the emoji grid.
+func (h *Handler) ShareHandler(w http.ResponseWriter, r *http.Request) {
+	share, ok := h.share(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(share)
+}
+
+// ShareImageHandler renders the results of the session as a card for social previews.
+//
+// Returns:
+//   - A PNG image of the results.
+func (h *Handler) ShareImageHandler(w http.ResponseWriter, r *http.Request) {
+	share, ok := h.share(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, share.Image()); err != nil {
+		writeError(w, err, "Error rendering share image")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+// share gets the results of the session on the quiz of the request,
+// writing the error to the response when it fails.
+func (h *Handler) share(w http.ResponseWriter, r *http.Request) (Share, bool) {
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error sharing results")
+		return Share{}, false
+	}
+
+	share, err := h.Service.GetShare(r.Context(), quizID, playerID(w, r))
+	if err != nil {
+		writeError(w, err, "Error sharing results")
+		return Share{}, false
+	}
+	return share, true
+}
+
+// StatsHandler returns the statistics of the player on the daily quizzes. The
+// optional category and difficulty query parameters select the daily quiz series.
+//
+// Returns:
+//   - A JSON object containing the games played, win rate, streaks and guess distribution.
+func (h *Handler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.Service.GetStats(r.Context(), playerID(w, r), edition(r))
+	if err != nil {
+		writeError(w, err, "Error getting player stats")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// QuizStatsHandler returns the aggregate statistics of today's quiz, or of the
+// quiz from the date in the URL, with its fastest solves.
+//
+// Returns:
+//   - A JSON object containing the number of players, solve rate, average attempts and fastest solves.
+func (h *Handler) QuizStatsHandler(w http.ResponseWriter, r *http.Request) {
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error getting quiz stats")
+		return
+	}
+
+	stats, err := h.Service.GetQuizStats(r.Context(), quizID, playerID(w, r))
+	if err != nil {
+		writeError(w, err, "Error getting quiz stats")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// LeaderboardHandler returns the best solves of today's quiz, or of the quiz
+// from the date in the URL. The limit query parameter is optional.
+//
+// Returns:
+//   - A JSON array containing the leaderboard entries, from the best solve.
+func (h *Handler) LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultLeaderboardSize)
+	if err != nil || limit < 1 || limit > maxLeaderboardSize {
+		http.Error(w, "invalid limit parameter", http.StatusBadRequest)
+		return
+	}
+
+	quizID, err := h.quizID(r)
+	if err != nil {
+		writeError(w, err, "Error getting leaderboard")
+		return
+	}
+
+	entries, err := h.Service.GetLeaderboard(r.Context(), quizID, playerID(w, r), limit)
+	if err != nil {
+		writeError(w, err, "Error getting leaderboard")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// ListCategoriesHandler returns the themed quiz categories.
+//
+// Returns:
+//   - A JSON array containing the categories.
+func (h *Handler) ListCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories := h.Service.Categories()
+	if categories == nil {
+		categories = []Category{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// quizID returns the ID of the quiz from the date in the URL, or of today's
+// quiz when there is none, in the edition selected by the query parameters.
+// Practice quiz IDs in the URL are used as they are.
+func (h *Handler) quizID(r *http.Request) (string, error) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		return h.Service.TodaysQuizID(edition(r)), nil
+	}
+	if isPracticeID(id) || isCustomID(id) {
+		return id, nil
+	}
+
+	parsedDate, err := ParseDailyID(id)
+	if err != nil {
+		return "", err
+	}
+	return edition(r).QuizID(parsedDate), nil
+}
+
+// edition returns the daily quiz series selected by the category, difficulty and mode query parameters.
+func edition(r *http.Request) Edition {
+	return NewEdition(r.URL.Query().Get("category"), r.URL.Query().Get("difficulty")).WithMode(r.URL.Query().Get("mode"))
+}
+
+// writeError maps the quiz errors to their HTTP status codes,
+// unexpected errors are logged and answered with the given message.
+func writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrEmptyGuess), errors.Is(err, ErrInvalidQuizID), errors.Is(err, ErrQueryTooShort),
+		errors.Is(err, ErrSkipNotAllowed), errors.Is(err, ErrInvalidCustomQuiz), errors.Is(err, ErrInvalidPlaylist),
+		errors.Is(err, ErrInvalidRounds):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrTrackNotFound), errors.Is(err, ErrArtistNotFound), errors.Is(err, ErrAlbumNotFound),
+		errors.Is(err, ErrQuizNotFound), errors.Is(err, ErrCategoryNotFound), errors.Is(err, ErrDifficultyNotFound),
+		errors.Is(err, ErrModeNotFound), errors.Is(err, ErrCoverNotFound), errors.Is(err, ErrAudioNotFound),
+		errors.Is(err, ErrPlaylistNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrCoverLocked), errors.Is(err, ErrAudioLocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrQuizSolved), errors.Is(err, ErrQuizNotRevealed), errors.Is(err, ErrQuizNotFinished),
+		errors.Is(err, ErrQuizSessionOver):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.As(err, new(*ErrNoValidQuiz)):
+		log.Printf("%s: %v", message, err)
+		http.Error(w, "no valid quiz could be generated, try again later", http.StatusServiceUnavailable)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// queryInt parses an integer query parameter, returning
+// the fallback when the parameter is missing.
+func queryInt(r *http.Request, key string, fallback int) (int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// playerID returns the player ID sent by the client in a header or a cookie,
+// generating a new one and setting it on the response when it's missing.
+func playerID(w http.ResponseWriter, r *http.Request) string {
+	if id := r.Header.Get(playerHeader); id != "" {
+		return id
+	}
+	if cookie, err := r.Cookie(playerCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	if id := r.Header.Get(sessionHeader); id != "" {
+		return id
+	}
+
+	id := newID()
+	w.Header().Set(playerHeader, id)
+	w.Header().Set(sessionHeader, id)
+	http.SetCookie(w, &http.Cookie{
+		Name:     playerCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   playerCookieAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+// newID generates a random hex encoded identifier.
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
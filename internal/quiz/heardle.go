@@ -0,0 +1,107 @@
+package quiz
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+)
+
+// audioClipSeconds is the length of the clip unlocked at each hint level in audio
+// mode, where the preview is the only clue. Every wrong guess or skip unlocks a longer
+// clip, and the session is lost once a guess has been made with the longest one.
+var audioClipSeconds = []int{
+	HintNone:         1,
+	HintGenres:       2,
+	HintReleaseYear:  4,
+	HintAlbumImage:   7,
+	HintArtists:      11,
+	HintAudioPreview: 16,
+}
+
+// generateAudioQuiz generates a classic quiz and downloads its audio preview, so the
+// validation can make sure the preview is long enough for every clip of the audio mode.
+// A preview that can't be downloaded is dropped, rejecting the quiz.
+//
+// Parameters:
+//   - r: The random source driving every choice of the generation.
+//   - category: The category constraining the picked track.
+//
+// Returns:
+//   - A Quiz object along with its downloaded preview.
+//   - An error if the quiz generation fails.
+func (s *service) generateAudioQuiz(r *rand.Rand, category Category) (Quiz, error) {
+	quiz, err := s.generateQuiz(r, category)
+	if err != nil {
+		return Quiz{}, err
+	}
+	return s.withPreview(quiz), nil
+}
+
+// withPreview downloads the audio preview of a quiz, to be validated and cached along
+// with the quiz. The preview URL is cleared when the download fails, rejecting the quiz.
+func (s *service) withPreview(quiz Quiz) Quiz {
+	if quiz.Track.AudioPreview == "" {
+		return quiz
+	}
+
+	// quizzes are validated before being stored, the download has its own timeout
+	preview, err := s.download(context.Background(), quiz.Track.AudioPreview, maxPreviewSize)
+	if err != nil {
+		log.Printf("Error downloading audio preview of track %s: %v", quiz.Track.ID, err)
+		quiz.Track.AudioPreview = ""
+		return quiz
+	}
+	quiz.preview = preview
+	return quiz
+}
+
+// outOfTries tells whether a session has used every try of a quiz in audio mode,
+// the other modes having no limit.
+func (q Quiz) outOfTries(session Session) bool {
+	return q.Mode == ModeAudio && len(session.Guesses) >= len(audioClipSeconds)
+}
+
+// audioClues sets the hints of a quiz in audio mode unlocked by a session,
+// the track being revealed once the session is finished.
+func (q Quiz) audioClues(clues *Clues, session Session) {
+	clues.AudioSeconds = q.audioSeconds(session)
+	clues.MaxAttempts = len(audioClipSeconds)
+	if session.Finished() {
+		clues.AlbumImage = q.Album.Image
+		clues.Artists = make([]string, len(q.Artists))
+		for i, artist := range q.Artists {
+			clues.Artists[i] = artist.Name
+		}
+		clues.AudioPreview = q.Track.AudioPreview
+	}
+}
+
+// Skip records a skipped try in the player's session on a quiz in audio mode,
+// unlocking the next clip without guessing. Skipping the last try loses the quiz.
+//
+// Parameters:
+//   - quizID: The ID of the quiz being played.
+//   - sessionID: The ID of the player's session.
+//
+// Returns:
+//   - A GuessResponse object marking the skip, with the unlocked clues.
+//   - An error if the quiz isn't in audio mode, the quiz was already finished or the session can't be saved.
+func (s *service) Skip(ctx context.Context, quizID, sessionID string) (GuessResponse, error) {
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return GuessResponse{}, err
+	}
+	if quiz.Mode != ModeAudio {
+		return GuessResponse{}, ErrSkipNotAllowed
+	}
+
+	session, err := s.getSession(ctx, sessionID, quiz)
+	if err != nil {
+		return GuessResponse{}, err
+	}
+	if session.Finished() {
+		return GuessResponse{}, ErrQuizSolved
+	}
+
+	return s.addGuess(ctx, quiz, session, GuessResult{Skipped: true, SharedGenres: []string{}})
+}
@@ -0,0 +1,166 @@
+package quiz
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	defaultLeaderboardSize = 10
+	maxLeaderboardSize     = 100
+	// attemptsWeight ranks solves by attempts first, then by time. The solve time
+	// in milliseconds is added to attempts * attemptsWeight in the leaderboard score.
+	attemptsWeight = 1e9
+)
+
+// QuizStats holds how hard a daily quiz was for everyone who played it.
+type QuizStats struct {
+	QuizID          string             `json:"quiz_id"`
+	Players         int64              `json:"players"`
+	Solved          int64              `json:"solved"`
+	SolveRate       float64            `json:"solve_rate"`       // from 0 to 1
+	AverageAttempts float64            `json:"average_attempts"` // of the players who solved it
+	Fastest         []LeaderboardEntry `json:"fastest"`
+}
+
+// LeaderboardEntry is a solve of a daily quiz. Players are only known by a
+// name derived from their ID, since the ID itself identifies their sessions.
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	Player   string `json:"player"`
+	Attempts int    `json:"attempts"`
+	TimeMs   int64  `json:"time_ms"` // from the quiz being first served to the solve
+	Me       bool   `json:"me"`      // whether it's the solve of the requesting player
+}
+
+// GetQuizStats returns the aggregate statistics of a daily quiz, along with its fastest solves.
+//
+// Parameters:
+//   - quizID: The ID of the daily quiz.
+//   - playerID: The ID of the requesting player, marked in the fastest solves.
+//
+// Returns:
+//   - A QuizStats object containing the number of players, solve rate and average attempts.
+//   - An error if the quiz is not found or the statistics can't be read.
+func (s *service) GetQuizStats(ctx context.Context, quizID, playerID string) (QuizStats, error) {
+	if _, err := s.GetQuiz(ctx, quizID); err != nil {
+		return QuizStats{}, err
+	}
+
+	players, err := s.repository.CountPlayers(ctx, quizID)
+	if err != nil {
+		log.Printf("Error counting players of quiz %s: %v", quizID, err)
+		return QuizStats{}, err
+	}
+	solved, err := s.repository.CountSolves(ctx, quizID)
+	if err != nil {
+		log.Printf("Error counting solves of quiz %s: %v", quizID, err)
+		return QuizStats{}, err
+	}
+	attempts, err := s.repository.GetSolveAttempts(ctx, quizID)
+	if err != nil {
+		log.Printf("Error getting attempts of quiz %s: %v", quizID, err)
+		return QuizStats{}, err
+	}
+	fastest, err := s.GetLeaderboard(ctx, quizID, playerID, defaultLeaderboardSize)
+	if err != nil {
+		return QuizStats{}, err
+	}
+
+	stats := QuizStats{
+		QuizID:  quizID,
+		Players: players,
+		Solved:  solved,
+		Fastest: fastest,
+	}
+	if players > 0 {
+		stats.SolveRate = float64(solved) / float64(players)
+	}
+	if solved > 0 {
+		stats.AverageAttempts = float64(attempts) / float64(solved)
+	}
+	return stats, nil
+}
+
+// GetLeaderboard returns the best solves of a daily quiz, ranked by the
+// number of attempts and then by the time taken.
+//
+// Parameters:
+//   - quizID: The ID of the daily quiz.
+//   - playerID: The ID of the requesting player, marked in the leaderboard.
+//   - limit: The number of entries to return.
+//
+// Returns:
+//   - A slice of LeaderboardEntry objects, from the best solve.
+//   - An error if the leaderboard can't be read.
+func (s *service) GetLeaderboard(ctx context.Context, quizID, playerID string, limit int) ([]LeaderboardEntry, error) {
+	solves, err := s.repository.GetLeaderboard(ctx, quizID, limit)
+	if err != nil {
+		log.Printf("Error getting leaderboard of quiz %s: %v", quizID, err)
+		return nil, err
+	}
+
+	entries := make([]LeaderboardEntry, len(solves))
+	for i, solve := range solves {
+		entries[i] = LeaderboardEntry{
+			Rank:     i + 1,
+			Player:   playerName(solve.Member),
+			Attempts: int(solve.Score / attemptsWeight),
+			TimeMs:   int64(math.Mod(solve.Score, attemptsWeight)),
+			Me:       solve.Member == playerID,
+		}
+	}
+	return entries, nil
+}
+
+// recordQuizStats adds a guess to the statistics of a quiz. Only today's daily quizzes
+// count, so everyone in the leaderboard played the quiz on the same terms. Solves are
+// timed from when the quiz was first served to the player (see GetClues), and solves
+// of players never served the quiz, who may have learned the answer elsewhere, are
+// counted but left out of the leaderboard.
+//
+// Parameters:
+//   - quiz: The quiz played.
+//   - session: The session of the player on the quiz, after the guess.
+//
+// Returns:
+//   - An error if the statistics can't be stored.
+func (s *service) recordQuizStats(ctx context.Context, quiz Quiz, session Session) error {
+	if quiz.ID != s.TodaysQuizID(quiz.Edition()) {
+		return nil
+	}
+
+	// players giving up before their first guess still played the quiz
+	if len(session.Guesses) <= 1 {
+		if err := s.repository.AddPlayer(ctx, quiz.ID, session.ID, session.StartedAt); err != nil {
+			return err
+		}
+	}
+	if !session.Solved {
+		return nil
+	}
+
+	var added bool
+	var err error
+	if session.Served {
+		elapsed := min(s.now().Sub(session.StartedAt), time.Duration(attemptsWeight-1)*time.Millisecond)
+		score := float64(len(session.Guesses))*attemptsWeight + float64(elapsed.Milliseconds())
+		added, err = s.repository.AddSolve(ctx, quiz.ID, session.ID, score)
+	} else {
+		added, err = s.repository.AddUnrankedSolve(ctx, quiz.ID, session.ID)
+	}
+	if err != nil || !added {
+		return err
+	}
+	return s.repository.AddSolveAttempts(ctx, quiz.ID, len(session.Guesses))
+}
+
+// playerName returns the public name of a player, derived from their ID.
+func playerName(playerID string) string {
+	hash := sha256.Sum256([]byte(playerID))
+	return "Player " + hex.EncodeToString(hash[:3])
+}
@@ -0,0 +1,119 @@
+package quiz
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultCorrectThreshold = 0.9
+	defaultCloseThreshold   = 0.7
+)
+
+// Match levels of a guessed title against the answer's.
+const (
+	matchWrong = iota
+	matchClose
+	matchCorrect
+)
+
+var (
+	// featuringPattern matches featuring credits, either between brackets or trailing the title.
+	featuringPattern = regexp.MustCompile(`[(\[]\s*(feat\.?|ft\.?|featuring|with)\s[^)\]]*[)\]]|\s(feat\.?|ft\.?|featuring)\s.*$`)
+	// versionPattern matches version suffixes, either between brackets or after a dash.
+	versionPattern = regexp.MustCompile(`[(\[][^)\]]*\b(remaster(ed)?|live|edit|remix|version|mono|stereo|acoustic|demo|deluxe)\b[^)\]]*[)\]]|\s-\s.*\b(remaster(ed)?|live|edit|remix|version|mono|stereo|acoustic|demo|deluxe)\b.*$`)
+)
+
+// accents maps the accented latin letters to their base letters.
+var accents = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// matcher scores free-text guesses against the title of the answer.
+type matcher struct {
+	correct float64 // similarity from which a title is the answer
+	close   float64 // similarity from which a title is close to the answer
+}
+
+// match compares a guessed title with the answer's title once both are normalized.
+//
+// Parameters:
+//   - guess: The title typed or picked by the player.
+//   - answer: The title of the quiz track.
+//
+// Returns:
+//   - The match level of the guess. (matchWrong, matchClose or matchCorrect)
+func (m matcher) match(guess, answer string) int {
+	score := similarity(normalizeTitle(guess), normalizeTitle(answer))
+	switch {
+	case score >= m.correct:
+		return matchCorrect
+	case score >= m.close:
+		return matchClose
+	default:
+		return matchWrong
+	}
+}
+
+// normalizeTitle reduces a track title to the words a player would type, dropping
+// the case, accents, punctuation, featuring credits and version suffixes.
+// ("Don't Stop Me Now - Remastered 2011" becomes "dont stop me now")
+func normalizeTitle(title string) string {
+	title = strings.ToLower(title)
+	title = featuringPattern.ReplaceAllString(title, "")
+	title = versionPattern.ReplaceAllString(title, "")
+	title = accents.Replace(title)
+	title = strings.ReplaceAll(title, "&", " and ")
+
+	var b strings.Builder
+	for _, r := range title {
+		switch {
+		case r == '\'' || r == '’' || r == '.':
+			// dropped so "don't" matches "dont" and "P.Y.T." matches "pyt"
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// similarity returns how similar two strings are, from 0 for completely
+// different strings to 1 for equal ones, based on their edit distance.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the number of insertions, deletions and
+// substitutions needed to turn one string into the other.
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
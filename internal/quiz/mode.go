@@ -0,0 +1,38 @@
+package quiz
+
+// Game modes of the quizzes. The classic mode is the zero value of Edition.Mode,
+// so classic quizzes keep their plain date IDs.
+const (
+	ModeClassic = "classic" // guess the track from its hints
+	ModeCover   = "cover"   // guess the album from its pixelated cover, sharpened by each wrong guess
+	ModeArtist  = "artist"  // guess the artist from their genres, followers, related artists and a snippet
+	ModeAlbum   = "album"   // guess the album from its track count, genres, release year, label and artists
+	ModeAudio   = "audio"   // guess the track from longer and longer clips of its preview, with a limited number of tries
+)
+
+// Modes lists the game modes, the alternate ones have to be enabled with WithModes.
+var Modes = []string{ModeClassic, ModeCover, ModeArtist, ModeAlbum, ModeAudio}
+
+// normalizeMode returns the mode as stored in an edition,
+// where the classic mode is left empty.
+func normalizeMode(mode string) string {
+	if mode == ModeClassic {
+		return ""
+	}
+	return mode
+}
+
+// mode returns the game mode name of the edition.
+func (e Edition) mode() string {
+	if e.Mode == "" {
+		return ModeClassic
+	}
+	return e.Mode
+}
+
+// WithMode returns the edition played in the given game mode,
+// which can be empty for the classic mode.
+func (e Edition) WithMode(mode string) Edition {
+	e.Mode = normalizeMode(mode)
+	return e
+}
@@ -6,16 +6,34 @@ import (
 )
 
 type Quiz struct {
-	Artists   []quizArtist `json:"artists"`
-	Album     quizAlbum    `json:"album"`
-	Track     quizSong     `json:"track"`
-	CreatedAt time.Time    `json:"created_at"`
+	ID       string `json:"id"`
+	Number   int    `json:"number"`
+	Category string `json:"category,omitempty"`
+	// Difficulty is the difficulty level of the quiz and DifficultyScore how hard
+	// its track is to guess, from 0 to 100. (see DifficultyScore)
+	Difficulty      string       `json:"difficulty,omitempty"`
+	DifficultyScore int          `json:"difficulty_score"`
+	Mode            string       `json:"mode,omitempty"`
+	Artists         []quizArtist `json:"artists"`
+	Album           quizAlbum    `json:"album"`
+	Track           quizSong     `json:"track"`
+	RelatedArtists  []string     `json:"related_artists,omitempty"` // of the answer in artist mode, the closest last
+	Title           string       `json:"title,omitempty"`           // of the custom quiz the quiz is a round of
+	Round           int          `json:"round,omitempty"`           // of the custom quiz, from 1
+	HintLadder      []int        `json:"hint_ladder,omitempty"`     // custom order of the hints, the classic one when empty
+	SessionID       string       `json:"session_id,omitempty"`      // session allowed to play a practice quiz
+	QuizSessionID   string       `json:"quiz_session_id,omitempty"` // of the quiz session the practice quiz is a round of
+	ExpiresAt       *time.Time   `json:"expires_at,omitempty"`      // only set for practice quizzes
+	CreatedAt       time.Time    `json:"created_at"`
+
+	preview []byte // audio preview downloaded by the generation in audio mode, cached once the quiz is stored
 }
 
 type quizArtist struct {
-	ID     string   `json:"id"`
-	Name   string   `json:"name"`
-	Genres []string `json:"genres"`
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Genres    []string `json:"genres"`
+	Followers int      `json:"followers"`
 }
 
 type quizAlbum struct {
@@ -23,15 +41,275 @@ type quizAlbum struct {
 	Name        string `json:"name"`
 	Image       string `json:"image"`
 	ReleaseDate string `json:"release_date"`
+	AlbumType   string `json:"album_type,omitempty"`
+	TotalTracks int    `json:"total_tracks,omitempty"`
+	Label       string `json:"label,omitempty"` // only known in album mode
 }
 
 type quizSong struct {
 	ID           string `json:"id"`
 	Name         string `json:"name"`
 	AudioPreview string `json:"audio_preview"`
+	Explicit     bool   `json:"explicit,omitempty"`
+}
+
+// Clues is the public view of a quiz. It never contains the answer and
+// only holds the hints unlocked so far by the player.
+type Clues struct {
+	ID              string     `json:"id"`
+	Number          int        `json:"number"`
+	Category        string     `json:"category,omitempty"`
+	Difficulty      string     `json:"difficulty,omitempty"`
+	DifficultyScore int        `json:"difficulty_score"`
+	Mode            string     `json:"mode,omitempty"`
+	Title           string     `json:"title,omitempty"` // only set for the rounds of custom quizzes
+	Round           int        `json:"round,omitempty"`
+	NextRollover    *time.Time `json:"next_rollover,omitempty"` // only set for today's quiz
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`    // only set for practice quizzes
+	Attempts        int        `json:"attempts"`
+	MaxAttempts     int        `json:"max_attempts,omitempty"` // tries allowed in audio mode, skips included
+	Solved          bool       `json:"solved"`
+	GaveUp          bool       `json:"gave_up,omitempty"`
+	CoverLevel      int        `json:"cover_level,omitempty"` // pixelation level of the cover unlocked, from 1
+	Followers       string     `json:"followers,omitempty"`   // order of magnitude of the artist's followers in artist mode
+	TotalTracks     int        `json:"total_tracks,omitempty"`
+	Label           string     `json:"label,omitempty"` // record label of the album to guess in album mode
+	Genres          []string   `json:"genres,omitempty"`
+	RelatedArtists  []string   `json:"related_artists,omitempty"`
+	ReleaseYear     string     `json:"release_year,omitempty"`
+	AlbumImage      string     `json:"album_image,omitempty"`
+	Artists         []string   `json:"artists,omitempty"`
+	AudioSeconds    int        `json:"audio_seconds,omitempty"` // length of the audio clip unlocked, played through the quiz audio endpoint
+	AudioPreview    string     `json:"audio_preview,omitempty"` // only set once the quiz is finished, its URL identifying the track
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Hint levels, each wrong guess unlocks the next one.
+const (
+	HintNone = iota
+	HintGenres
+	HintReleaseYear
+	HintAlbumImage
+	HintArtists
+	HintAudioPreview
+)
+
+// QuizSummary is an entry of the quiz archive.
+type QuizSummary struct {
+	ID        string    `json:"id"`
+	Number    int       `json:"number"`
+	CreatedAt time.Time `json:"created_at"`
+	Revealed  bool      `json:"revealed"` // whether the answer can already be fetched
+}
+
+// Session holds the progress of a player on a quiz. Its ID is the player's ID.
+type Session struct {
+	ID        string        `json:"id"`
+	QuizID    string        `json:"quiz_id"`
+	Guesses   []GuessResult `json:"guesses"`
+	Solved    bool          `json:"solved"`
+	GaveUp    bool          `json:"gave_up,omitempty"`
+	StartedAt time.Time     `json:"started_at"`       // when today's quiz was first served, the first guess otherwise
+	Served    bool          `json:"served,omitempty"` // whether today's quiz was served before the first guess
+	// FinishedAt is when the quiz was solved or given up, by the server's clock.
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Guess is a player's attempt at today's quiz. Either the track ID
+// or the track name must be set, the ID takes precedence. In artist mode,
+// either the artist ID or the artist name must be set instead, and in
+// album mode either the album ID or the album name.
+type Guess struct {
+	TrackID    string `json:"track_id"`
+	TrackName  string `json:"track_name"`
+	ArtistID   string `json:"artist_id,omitempty"`
+	ArtistName string `json:"artist_name,omitempty"`
+	AlbumID    string `json:"album_id,omitempty"`
+	AlbumName  string `json:"album_name,omitempty"`
+}
+
+// GuessResult holds the per-attribute feedback of a guess.
+type GuessResult struct {
+	Correct      bool     `json:"correct"`
+	Skipped      bool     `json:"skipped,omitempty"` // a try skipped in audio mode, without any feedback
+	TrackID      string   `json:"track_id"`
+	TrackName    string   `json:"track_name"`
+	Close        bool     `json:"close"` // the title is almost the answer's, without matching it
+	SameArtist   bool     `json:"same_artist"`
+	SameAlbum    bool     `json:"same_album"`
+	SharedGenres []string `json:"shared_genres"`
+	ReleaseYear  string   `json:"release_year"` // where the answer's release year is relative to the guess
+	// artist mode feedback, telling where the answer's follower count is relative to
+	// the guessed artist's and how many orders of magnitude they are apart
+	ArtistID         string  `json:"artist_id,omitempty"`
+	ArtistName       string  `json:"artist_name,omitempty"`
+	Followers        string  `json:"followers,omitempty"` // FollowersHigher, FollowersLower or FollowersEqual
+	FollowerDistance float64 `json:"follower_distance,omitempty"`
+	// album mode feedback, along with the genres and release year
+	AlbumID       string   `json:"album_id,omitempty"`
+	AlbumName     string   `json:"album_name,omitempty"`
+	SharedArtists []string `json:"shared_artists,omitempty"`
 }
+
+// GuessResponse is the feedback of a guess along with the clues unlocked after it.
+type GuessResponse struct {
+	GuessResult
+	Clues Clues `json:"clues"`
+}
+
+const (
+	YearHigher = "higher" // the answer was released after the guessed track
+	YearLower  = "lower"  // the answer was released before the guessed track
+	YearEqual  = "equal"
+)
+
+const (
+	FollowersHigher = "higher" // the answer has more followers than the guessed artist
+	FollowersLower  = "lower"  // the answer has fewer followers than the guessed artist
+	FollowersEqual  = "equal"
+)
+
 type Service interface {
-	GetTodaysQuiz(context.Context) (Quiz, error)
+	Categories() []Category
+	TodaysQuizID(edition Edition) string
+	GetTodaysQuiz(ctx context.Context, edition Edition) (Quiz, error)
+	FillBuffer(ctx context.Context, days int) (int, error)
+	GetQuiz(ctx context.Context, id string) (Quiz, error)
+	GetAnswer(ctx context.Context, id string) (Quiz, error)
+	ListQuizzes(ctx context.Context, edition Edition, page, limit int) ([]QuizSummary, error)
+	GetClues(ctx context.Context, quizID, sessionID string) (Clues, error)
+	Guess(ctx context.Context, quizID, sessionID string, guess Guess) (GuessResponse, error)
+	GiveUp(ctx context.Context, quizID, sessionID string) (Quiz, error)
+	GetCover(ctx context.Context, quizID, sessionID string, level int) (Cover, error)
+	GetAudio(ctx context.Context, quizID, sessionID string, seconds int) (Audio, error)
+	Skip(ctx context.Context, quizID, sessionID string) (GuessResponse, error)
+	CreateCustomQuiz(ctx context.Context, req CustomQuizRequest) (CustomQuiz, error)
+	GetCustomQuiz(ctx context.Context, code string) (CustomQuiz, error)
+	NewPlaylistQuiz(ctx context.Context, req PlaylistQuizRequest) (CustomQuiz, error)
+	NewPracticeQuiz(ctx context.Context, edition Edition, sessionID string) (Clues, error)
+	NewQuizSession(ctx context.Context, edition Edition, playerID string, rounds int) (QuizSession, error)
+	NextRound(ctx context.Context, id, playerID string) (Clues, error)
+	GetQuizSession(ctx context.Context, id, playerID string) (QuizSession, error)
+	Autocomplete(ctx context.Context, query string) ([]Suggestion, error)
+	GetShare(ctx context.Context, quizID, sessionID string) (Share, error)
+	GetStats(ctx context.Context, playerID string, edition Edition) (PlayerStats, error)
+	GetQuizStats(ctx context.Context, quizID, playerID string) (QuizStats, error)
+	GetLeaderboard(ctx context.Context, quizID, playerID string, limit int) ([]LeaderboardEntry, error)
+}
+
+// firstQuizDate is the date of the daily quiz number 1.
+var firstQuizDate = time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+// DailyID returns the ID of the daily quiz of the given date.
+func DailyID(date time.Time) string {
+	return date.Format(time.DateOnly)
+}
+
+// ParseDailyID returns the date of a daily quiz ID.
+func ParseDailyID(id string) (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, id)
+	if err != nil {
+		return time.Time{}, ErrInvalidQuizID
+	}
+	return date, nil
+}
+
+// QuizNumber returns the sequential number of the daily quiz of the given date.
+func QuizNumber(date time.Time) int {
+	year, month, day := date.Date()
+	days := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Sub(firstQuizDate).Hours() / 24
+	return int(days) + 1
+}
+
+// Edition returns the daily quiz series the quiz belongs to.
+func (q Quiz) Edition() Edition {
+	return NewEdition(q.Category, q.Difficulty).WithMode(q.Mode)
+}
+
+// Clues returns the hints of a quiz unlocked by a session, without
+// revealing the track or the album. The album image is only revealed once the
+// session is finished, the cover being served pixelated until then.
+func (q Quiz) Clues(session Session) Clues {
+	clues := Clues{
+		ID:              q.ID,
+		Number:          q.Number,
+		Category:        q.Category,
+		Difficulty:      q.Difficulty,
+		DifficultyScore: q.DifficultyScore,
+		Mode:            q.Mode,
+		Title:           q.Title,
+		Round:           q.Round,
+		ExpiresAt:       q.ExpiresAt,
+		Attempts:        len(session.Guesses),
+		Solved:          session.Solved,
+		GaveUp:          session.GaveUp,
+		CreatedAt:       q.CreatedAt,
+	}
+	switch q.Mode {
+	case ModeArtist:
+		q.artistClues(&clues, session)
+		return clues
+	case ModeAlbum:
+		q.albumClues(&clues, session)
+		return clues
+	case ModeAudio:
+		q.audioClues(&clues, session)
+		return clues
+	}
+
+	level := session.HintLevel()
+	if level >= q.unlockLevel(HintGenres) {
+		clues.Genres = q.Genres()
+	}
+	if level >= q.unlockLevel(HintReleaseYear) {
+		clues.ReleaseYear = releaseYear(q.Album.ReleaseDate)
+	}
+	// the cover is pixelated through the cover endpoint until the quiz is finished
+	if session.Finished() {
+		clues.AlbumImage = q.Album.Image
+	} else if q.coverUnlocked(session) {
+		clues.CoverLevel = level + 1
+	}
+	if level >= q.unlockLevel(HintArtists) {
+		clues.Artists = make([]string, len(q.Artists))
+		for i, artist := range q.Artists {
+			clues.Artists[i] = artist.Name
+		}
+	}
+	clues.AudioSeconds = q.audioSeconds(session)
+	if session.Finished() {
+		clues.AudioPreview = q.Track.AudioPreview
+	}
+	return clues
+}
+
+// HintLevel returns how many hints the session has unlocked. Every wrong guess
+// unlocks one more hint and solving or giving up the quiz unlocks all of them.
+func (s Session) HintLevel() int {
+	if s.Finished() {
+		return HintAudioPreview
+	}
+	return min(len(s.Guesses), HintAudioPreview)
+}
+
+// Finished tells whether the quiz is over for the session, either solved or given up.
+func (s Session) Finished() bool {
+	return s.Solved || s.GaveUp
+}
+
+// Genres returns the deduplicated genres of all the quiz artists.
+func (q Quiz) Genres() []string {
+	genres := []string{}
+	seen := make(map[string]bool)
+	for _, artist := range q.Artists {
+		for _, genre := range artist.Genres {
+			if !seen[genre] {
+				seen[genre] = true
+				genres = append(genres, genre)
+			}
+		}
+	}
+	return genres
 }
 
 func (q Quiz) String() string {
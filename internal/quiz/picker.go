@@ -0,0 +1,272 @@
+package quiz
+
+import (
+	"backendProject/internal/spotify"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+)
+
+// Names of the available track pickers, used to select them in the configuration.
+const (
+	PickerRecommendations = "recommendations"
+	PickerSearch          = "search"
+	PickerTopTracks       = "top-tracks"
+	PickerCurated         = "curated"
+)
+
+const maxPickerAttempts = 5
+
+// ErrNoTrackPicked is returned by a TrackPicker when it can't find a
+// suitable track, so the next picker is used instead.
+var ErrNoTrackPicked = errors.New("no suitable track found")
+
+// TrackPicker is a strategy to pick the track of a quiz.
+type TrackPicker interface {
+	Name() string
+	// PickTrack picks a track with a preview URL that meets the constraints
+	// of the category, driven by the given random source.
+	PickTrack(r *rand.Rand, category Category) (spotify.Track, error)
+}
+
+// NewTrackPicker creates the track picker with the given name.
+//
+// Parameters:
+//   - name: The name of the track picker. (recommendations, search, top-tracks, curated)
+//   - spotifyService: The Spotify service used to find tracks.
+//   - curatedPlaylist: The link or ID of the public playlist used by the curated picker.
+//
+// Returns:
+//   - The TrackPicker with the given name.
+//   - An error if there is no track picker with the given name, or the curated playlist is invalid.
+func NewTrackPicker(name string, spotifyService spotify.Service, curatedPlaylist string) (TrackPicker, error) {
+	switch name {
+	case PickerRecommendations:
+		return &recommendationsPicker{spotifyService: spotifyService}, nil
+	case PickerSearch:
+		return &searchPicker{spotifyService: spotifyService}, nil
+	case PickerTopTracks:
+		return &topTracksPicker{spotifyService: spotifyService}, nil
+	case PickerCurated:
+		playlistID, err := parsePlaylistID(curatedPlaylist)
+		if err != nil {
+			return nil, fmt.Errorf("curated track picker needs a playlist: %w", err)
+		}
+		return &curatedPicker{spotifyService: spotifyService, playlistID: playlistID}, nil
+	default:
+		return nil, fmt.Errorf("unknown track picker %q", name)
+	}
+}
+
+// pickTrack picks a track using each picker in order, falling
+// back to the next one when a picker fails to find a track.
+//
+// Parameters:
+//   - pickers: The track pickers, in order of preference.
+//   - r: The random source used by the pickers.
+//   - category: The category constraining the picked track.
+//
+// Returns:
+//   - A Spotify track object containing the picked track data.
+//   - An error if every picker fails.
+func pickTrack(pickers []TrackPicker, r *rand.Rand, category Category) (spotify.Track, error) {
+	var errs []error
+	for _, picker := range pickers {
+		track, err := picker.PickTrack(r, category)
+		if err == nil {
+			log.Printf("Picked track %s using the %s picker", track.ID, picker.Name())
+			return track, nil
+		}
+
+		log.Printf("The %s picker failed, falling back to the next one: %v", picker.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", picker.Name(), err))
+	}
+	return spotify.Track{}, errors.Join(errs...)
+}
+
+// pickWithPreview picks a random track with a preview URL allowed by the
+// category, within its popularity range when popularity is checked.
+func pickWithPreview(r *rand.Rand, tracks []spotify.Track, category Category, popularity bool) (spotify.Track, error) {
+	candidates := []spotify.Track{}
+	for _, track := range tracks {
+		if track.PreviewURL != "" && category.allows(track) && (!popularity || category.popular(track)) {
+			candidates = append(candidates, track)
+		}
+	}
+	if len(candidates) == 0 {
+		return spotify.Track{}, ErrNoTrackPicked
+	}
+	return candidates[r.IntN(len(candidates))], nil
+}
+
+// recommendationsPicker picks a track from Spotify's recommendations,
+// seeded with a random track found by a wildcard search.
+type recommendationsPicker struct {
+	spotifyService spotify.Service
+}
+
+func (p *recommendationsPicker) Name() string {
+	return PickerRecommendations
+}
+
+func (p *recommendationsPicker) PickTrack(r *rand.Rand, category Category) (spotify.Track, error) {
+	for attempts := 0; attempts < maxPickerAttempts; attempts++ {
+		randomTracks, err := p.spotifyService.Search(category.searchQuery(r, spotify.RandomWildcard(r)), "track", category.options()...)
+		if err != nil {
+			log.Printf("Error searching for a random song: %v", err)
+			return spotify.Track{}, err
+		}
+		if len(randomTracks.Tracks.Items) == 0 {
+			log.Printf("No tracks found with the current wildcard, retrying")
+			continue
+		}
+
+		randomTrack := randomTracks.Tracks.Items[r.IntN(len(randomTracks.Tracks.Items))]
+
+		track, err := p.getRandomTrack(r, category, artistIDs(randomTrack.Album.Artists, 5), randomTrack.ID)
+		if err != nil {
+			switch err.(type) {
+			case *spotify.ErrRecommendationsEmpty:
+				log.Printf("No recommendations found with the current seed, retrying")
+				continue // retry if no recommendations are found with the current seed
+			default:
+				return spotify.Track{}, err
+			}
+		}
+		return track, nil
+	}
+	return spotify.Track{}, ErrNoTrackPicked
+}
+
+// getRandomTrack retrieves a random recommended track from Spotify's API based on a list of artist IDs and a random track ID.
+//
+// Parameters:
+//   - r: The random source used to pick the track.
+//   - category: The category whose genres are used as seed genres and whose market and popularity range are used.
+//   - artistIDs: A slice of artist IDs to use as seed artists.
+//   - randomTrackID: A random track ID to use as a seed track.
+//
+// Returns:
+//   - A Spotify track object containing the random recommended track data.
+//   - An error if the request fails.
+func (p *recommendationsPicker) getRandomTrack(r *rand.Rand, category Category, artistIDs []string, randomTrackID string) (spotify.Track, error) {
+	attempts := 0
+	maxAttempts := 10
+	for attempts < maxAttempts {
+		// limit the number of seeds to 5. up to 2 genres, the artists and the random track
+		seedGenres := category.seedGenres(r, 2)
+		seedArtists := artistIDs
+		if len(seedArtists) > 4-len(seedGenres) {
+			seedArtists = seedArtists[:4-len(seedGenres)]
+		}
+
+		recommendedTracks, err := p.spotifyService.GetRecommendations(seedArtists, seedGenres, []string{randomTrackID}, category.MinPopularity,
+			append(category.options(), spotify.WithMaxPopularity(category.MaxPopularity))...)
+		if err != nil {
+			log.Printf("Error getting recommendations from random song: %v", err)
+			return spotify.Track{}, err
+		}
+		if len(recommendedTracks.Tracks) == 0 {
+			return spotify.Track{}, &spotify.ErrRecommendationsEmpty{Message: "no recommendations found"}
+		}
+
+		for j := 0; j < 10; j++ {
+			recommendedTrack := recommendedTracks.Tracks[r.IntN(len(recommendedTracks.Tracks))]
+
+			// return the first track found with a preview URL
+			if recommendedTrack.PreviewURL != "" && category.allows(recommendedTrack) && category.popular(recommendedTrack) {
+				return recommendedTrack, nil
+			}
+		}
+		attempts++
+	}
+	return spotify.Track{}, fmt.Errorf("could not find a recommended track with a preview URL after %d attempts", maxAttempts)
+}
+
+// searchPicker picks a track in the popularity range from a random wildcard search.
+type searchPicker struct {
+	spotifyService spotify.Service
+}
+
+func (p *searchPicker) Name() string {
+	return PickerSearch
+}
+
+func (p *searchPicker) PickTrack(r *rand.Rand, category Category) (spotify.Track, error) {
+	for attempts := 0; attempts < maxPickerAttempts; attempts++ {
+		randomTracks, err := p.spotifyService.Search(category.searchQuery(r, spotify.RandomWildcard(r)), "track", category.options()...)
+		if err != nil {
+			log.Printf("Error searching for a random song: %v", err)
+			return spotify.Track{}, err
+		}
+
+		track, err := pickWithPreview(r, randomTracks.Tracks.Items, category, true)
+		if err == nil {
+			return track, nil
+		}
+	}
+	return spotify.Track{}, ErrNoTrackPicked
+}
+
+// topTracksPicker picks one of the top tracks of the
+// artist of a track found by a random wildcard search.
+type topTracksPicker struct {
+	spotifyService spotify.Service
+}
+
+func (p *topTracksPicker) Name() string {
+	return PickerTopTracks
+}
+
+func (p *topTracksPicker) PickTrack(r *rand.Rand, category Category) (spotify.Track, error) {
+	for attempts := 0; attempts < maxPickerAttempts; attempts++ {
+		randomTracks, err := p.spotifyService.Search(category.searchQuery(r, spotify.RandomWildcard(r)), "track", category.options()...)
+		if err != nil {
+			log.Printf("Error searching for a random song: %v", err)
+			return spotify.Track{}, err
+		}
+		if len(randomTracks.Tracks.Items) == 0 {
+			continue
+		}
+
+		randomTrack := randomTracks.Tracks.Items[r.IntN(len(randomTracks.Tracks.Items))]
+		if len(randomTrack.Album.Artists) == 0 {
+			continue
+		}
+
+		topTracks, err := p.spotifyService.GetArtistTopTracks(randomTrack.Album.Artists[0].ID, category.options()...)
+		if err != nil {
+			log.Printf("Error getting the artist's top tracks: %v", err)
+			return spotify.Track{}, err
+		}
+
+		track, err := pickWithPreview(r, topTracks.Tracks, category, true)
+		if err == nil {
+			return track, nil
+		}
+	}
+	return spotify.Track{}, ErrNoTrackPicked
+}
+
+// curatedPicker picks a track from a curated public playlist.
+type curatedPicker struct {
+	spotifyService spotify.Service
+	playlistID     string
+}
+
+func (p *curatedPicker) Name() string {
+	return PickerCurated
+}
+
+func (p *curatedPicker) PickTrack(r *rand.Rand, category Category) (spotify.Track, error) {
+	// the playlist is read on every pick, so its curators can edit it at any time
+	tracks, err := p.spotifyService.GetPlaylistTracks(p.playlistID)
+	if err != nil {
+		log.Printf("Error getting tracks of curated playlist %s: %v", p.playlistID, err)
+		return spotify.Track{}, err
+	}
+	// curated tracks are picked regardless of their popularity, the
+	// difficulty score of the generated quiz still ranks them
+	return pickWithPreview(r, tracks.Tracks, category, false)
+}
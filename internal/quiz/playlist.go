@@ -0,0 +1,152 @@
+package quiz
+
+import (
+	"backendProject/internal/spotify"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+const (
+	// defaultPlaylistRounds is the number of rounds of a playlist quiz when none is requested.
+	defaultPlaylistRounds = 10
+	defaultPlaylistTitle  = "Playlist quiz"
+	playlistURIPrefix     = "spotify:playlist:"
+	spotifyIDLength       = 22
+)
+
+// PlaylistQuizRequest holds the settings of a quiz made from the tracks of a public playlist.
+type PlaylistQuizRequest struct {
+	// Playlist is the link of the playlist, like https://open.spotify.com/playlist/<id>,
+	// its URI or its ID.
+	Playlist string   `json:"playlist"`
+	Title    string   `json:"title,omitempty"`
+	Mode     string   `json:"mode,omitempty"`   // classic, cover or audio, classic by default
+	Rounds   int      `json:"rounds,omitempty"` // tracks picked at random from the playlist, 10 by default
+	Hints    []string `json:"hints,omitempty"`  // the hint ladder, as in a custom quiz
+}
+
+// NewPlaylistQuiz builds a custom quiz from the tracks of a public playlist, picking
+// the rounds at random among the tracks having an audio preview. Tracks that can't be
+// played are skipped rather than rejecting the quiz, as the playlist isn't curated.
+//
+// Parameters:
+//   - req: The playlist and the settings of the quiz.
+//
+// Returns:
+//   - A CustomQuiz object with the share code and the IDs of its rounds.
+//   - An ErrInvalidPlaylist error if the playlist can't be parsed, an ErrPlaylistNotFound error
+//     if it isn't public, an ErrInvalidCustomQuiz error if the settings are invalid or the playlist
+//     has too few playable tracks, or an error if the requests fail.
+func (s *service) NewPlaylistQuiz(ctx context.Context, req PlaylistQuizRequest) (CustomQuiz, error) {
+	playlistID, err := parsePlaylistID(req.Playlist)
+	if err != nil {
+		return CustomQuiz{}, err
+	}
+
+	if strings.TrimSpace(req.Title) == "" {
+		req.Title = defaultPlaylistTitle
+	}
+	title, err := checkTitle(req.Title)
+	if err != nil {
+		return CustomQuiz{}, err
+	}
+	mode, ladder, err := parseHintLadder(req.Mode, req.Hints)
+	if err != nil {
+		return CustomQuiz{}, err
+	}
+	if req.Rounds < 0 || req.Rounds > maxCustomTracks {
+		return CustomQuiz{}, fmt.Errorf("%w: expected 1 to %d rounds", ErrInvalidCustomQuiz, maxCustomTracks)
+	}
+
+	tracks, err := s.playlistTracks(playlistID)
+	if err != nil {
+		return CustomQuiz{}, err
+	}
+	rounds := req.Rounds
+	if rounds == 0 {
+		rounds = defaultPlaylistRounds
+	}
+
+	custom := CustomQuiz{Title: title, Mode: mode, Hints: req.Hints}
+	custom, quizzes, err := s.buildCustomRounds(ctx, custom, ladder, tracks, rounds, false)
+	if err != nil {
+		return CustomQuiz{}, err
+	}
+	// the default number of rounds is only an upper bound for small playlists
+	if len(quizzes) == 0 || req.Rounds != 0 && len(quizzes) < req.Rounds {
+		return CustomQuiz{}, fmt.Errorf("%w: the playlist has %d playable tracks, expected %d", ErrInvalidCustomQuiz, len(quizzes), max(req.Rounds, 1))
+	}
+	return s.storeCustomQuiz(ctx, custom, quizzes)
+}
+
+// playlistTracks retrieves the tracks of a playlist having an audio preview, each track once.
+func (s *service) playlistTracks(playlistID string) ([]spotify.Track, error) {
+	response, err := s.spotifyService.GetPlaylistTracks(playlistID)
+	if errors.Is(err, spotify.ErrPlaylistNotFound) {
+		return nil, ErrPlaylistNotFound
+	}
+	if err != nil {
+		log.Printf("Error getting tracks of playlist %s: %v", playlistID, err)
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	tracks := []spotify.Track{}
+	for _, track := range response.Tracks {
+		if track.PreviewURL == "" || seen[track.ID] {
+			continue
+		}
+		seen[track.ID] = true
+		tracks = append(tracks, track)
+	}
+	log.Printf("Playlist %s has %d of %d tracks with a preview", playlistID, len(tracks), len(response.Tracks))
+	return tracks, nil
+}
+
+// parsePlaylistID extracts the ID of a playlist from its link, its URI or the ID itself.
+//
+// Parameters:
+//   - playlist: The link of the playlist, like https://open.spotify.com/playlist/<id>?si=..., its URI or its ID.
+//
+// Returns:
+//   - The ID of the playlist.
+//   - An ErrInvalidPlaylist error if no playlist ID is found.
+func parsePlaylistID(playlist string) (string, error) {
+	id := strings.TrimSpace(playlist)
+	if after, ok := strings.CutPrefix(id, playlistURIPrefix); ok {
+		id = after
+	} else if strings.Contains(id, "/") {
+		link, err := url.Parse(id)
+		if err != nil || !strings.HasSuffix(link.Hostname(), "spotify.com") {
+			return "", ErrInvalidPlaylist
+		}
+		// links may have a locale before the playlist, like /intl-fr/playlist/<id>
+		_, id, ok = strings.Cut(link.Path, "/playlist/")
+		if !ok {
+			return "", ErrInvalidPlaylist
+		}
+		id = strings.TrimSuffix(id, "/")
+	}
+
+	if !isSpotifyID(id) {
+		return "", ErrInvalidPlaylist
+	}
+	return id, nil
+}
+
+// isSpotifyID tells whether a string is a Spotify ID, made of 22 base62 characters.
+func isSpotifyID(id string) bool {
+	if len(id) != spotifyIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package quiz
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// practicePrefix starts the IDs of practice quizzes, telling them apart from the daily ones.
+	practicePrefix     = "practice-"
+	defaultPracticeTTL = 2 * time.Hour
+)
+
+// isPracticeID tells whether a quiz ID belongs to a practice quiz.
+func isPracticeID(id string) bool {
+	return strings.HasPrefix(id, practicePrefix)
+}
+
+// NewPracticeQuiz generates a throwaway quiz for a session, through the same pipeline
+// as the daily quiz. Practice quizzes expire after a while and can only be played by
+// the session that created them.
+//
+// Parameters:
+//   - edition: The category, difficulty and mode of the practice quiz, the date is not used.
+//   - sessionID: The ID of the player's session.
+//
+// Returns:
+//   - A Clues object with the opaque ID of the new quiz, used to send guesses.
+//   - An error if the category, difficulty or mode doesn't exist or the quiz generation fails.
+func (s *service) NewPracticeQuiz(ctx context.Context, edition Edition, sessionID string) (Clues, error) {
+	quiz, err := s.newPracticeQuiz(ctx, edition, sessionID, s.practiceTTL, "")
+	if err != nil {
+		return Clues{}, err
+	}
+
+	session, err := s.getSession(ctx, sessionID, quiz)
+	if err != nil {
+		return Clues{}, err
+	}
+	return s.clues(quiz, session), nil
+}
+
+// newPracticeQuiz generates and stores a practice quiz for a session.
+//
+// Parameters:
+//   - edition: The category, difficulty and mode of the practice quiz.
+//   - sessionID: The ID of the only session allowed to play the quiz.
+//   - ttl: How long the quiz can be played.
+//   - quizSessionID: The ID of the quiz session the quiz is a round of, if any.
+//
+// Returns:
+//   - The stored Quiz object.
+//   - An error if the category, difficulty or mode doesn't exist, the quiz generation fails or the quiz can't be stored.
+func (s *service) newPracticeQuiz(ctx context.Context, edition Edition, sessionID string, ttl time.Duration, quizSessionID string) (Quiz, error) {
+	category, err := s.category(edition)
+	if err != nil {
+		return Quiz{}, err
+	}
+
+	id := practicePrefix + newID()
+	quiz, err := s.generateQuizForLevel(s.generator(edition), s.randForQuiz(id), category, difficultyLevels[edition.difficulty()])
+	if err != nil {
+		return Quiz{}, err
+	}
+
+	expiresAt := s.now().Add(ttl)
+	quiz.ID = id
+	quiz.Category = edition.Category
+	quiz.Difficulty = edition.difficulty()
+	quiz.Mode = edition.mode()
+	quiz.SessionID = sessionID
+	quiz.QuizSessionID = quizSessionID
+	quiz.ExpiresAt = &expiresAt
+	if quiz.Mode == ModeCover {
+		if err := s.storeCover(ctx, quiz, ttl); err != nil {
+			return Quiz{}, err
+		}
+	}
+	err = s.repository.SetPracticeQuiz(ctx, quiz, ttl)
+	if err != nil {
+		log.Printf("Error setting practice quiz %s: %v", quiz.ID, err)
+		return Quiz{}, err
+	}
+	if quiz.preview != nil {
+		s.cachePreview(ctx, quiz, quiz.preview)
+	}
+	return quiz, nil
+}
+
+// getPracticeQuiz returns the practice quiz with the given ID, as long as it hasn't expired.
+func (s *service) getPracticeQuiz(ctx context.Context, id string) (Quiz, error) {
+	quiz, err := s.repository.GetPracticeQuiz(ctx, id)
+	if err != nil {
+		log.Printf("Error getting practice quiz %s: %v", id, err)
+		return Quiz{}, err
+	}
+	if quiz.CreatedAt.IsZero() {
+		return Quiz{}, ErrQuizNotFound
+	}
+	return quiz, nil
+}
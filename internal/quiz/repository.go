@@ -4,6 +4,7 @@ import (
 	"backendProject/internal/db"
 	"context"
 	"log"
+	"time"
 )
 
 type Repository struct {
@@ -28,3 +29,234 @@ func (r *Repository) SetQuiz(ctx context.Context, key string, quiz Quiz) error {
 	log.Printf("Setting quiz with key: %s", key)
 	return r.DB.SetObject(ctx, key, quiz)
 }
+
+// GetQuizByDate retrieves the daily quiz of an edition on the given date.
+func (r *Repository) GetQuizByDate(ctx context.Context, edition Edition, date time.Time) (Quiz, error) {
+	return r.GetQuiz(ctx, dailyQuizKey(edition, date))
+}
+
+// SetQuizByDate stores the daily quiz of an edition on the given date.
+func (r *Repository) SetQuizByDate(ctx context.Context, edition Edition, date time.Time, quiz Quiz) error {
+	return r.SetQuiz(ctx, dailyQuizKey(edition, date), quiz)
+}
+
+// AddQuizByDate stores the daily quiz of an edition on the given date, unless one
+// is already stored. It returns whether the quiz was stored.
+func (r *Repository) AddQuizByDate(ctx context.Context, edition Edition, date time.Time, quiz Quiz) (bool, error) {
+	key := dailyQuizKey(edition, date)
+	log.Printf("Adding quiz with key: %s", key)
+	return r.DB.SetObjectIfAbsent(ctx, key, quiz)
+}
+
+// LockQuizByDate acquires the lock to generate the daily quiz of an edition on the given date.
+func (r *Repository) LockQuizByDate(ctx context.Context, edition Edition, date time.Time, ttl time.Duration) (db.UnlockFunc, error) {
+	return r.DB.Lock(ctx, "lock:"+dailyQuizKey(edition, date), ttl)
+}
+
+// GetPracticeQuiz retrieves a practice quiz, which is not found once it expired.
+func (r *Repository) GetPracticeQuiz(ctx context.Context, id string) (Quiz, error) {
+	return r.GetQuiz(ctx, practiceQuizKey(id))
+}
+
+// SetPracticeQuiz stores a practice quiz, expiring after ttl.
+func (r *Repository) SetPracticeQuiz(ctx context.Context, quiz Quiz, ttl time.Duration) error {
+	log.Printf("Setting practice quiz with key: %s", practiceQuizKey(quiz.ID))
+	return r.DB.SetObjectWithTTL(ctx, practiceQuizKey(quiz.ID), quiz, ttl)
+}
+
+// GetCustomQuiz retrieves the custom quiz shared with the given code.
+func (r *Repository) GetCustomQuiz(ctx context.Context, code string) (CustomQuiz, error) {
+	custom := CustomQuiz{}
+	err := r.DB.GetObject(ctx, customQuizKey(code), &custom)
+	return custom, err
+}
+
+// AddCustomQuiz stores a custom quiz under its share code, unless another custom
+// quiz already has the code. It returns whether the custom quiz was stored.
+func (r *Repository) AddCustomQuiz(ctx context.Context, custom CustomQuiz) (bool, error) {
+	log.Printf("Adding custom quiz with key: %s", customQuizKey(custom.Code))
+	return r.DB.SetObjectIfAbsent(ctx, customQuizKey(custom.Code), custom)
+}
+
+// GetCustomRound retrieves a round of a custom quiz.
+func (r *Repository) GetCustomRound(ctx context.Context, id string) (Quiz, error) {
+	return r.GetQuiz(ctx, customRoundKey(id))
+}
+
+// SetCustomRound stores a round of a custom quiz.
+func (r *Repository) SetCustomRound(ctx context.Context, quiz Quiz) error {
+	return r.SetQuiz(ctx, customRoundKey(quiz.ID), quiz)
+}
+
+// GetQuizSession retrieves a quiz session of a player.
+func (r *Repository) GetQuizSession(ctx context.Context, playerID, id string) (QuizSession, error) {
+	quizSession := QuizSession{}
+	err := r.DB.GetObject(ctx, quizSessionKey(playerID, id), &quizSession)
+	return quizSession, err
+}
+
+// SetQuizSession stores a quiz session of a player, expiring after ttl.
+func (r *Repository) SetQuizSession(ctx context.Context, playerID string, quizSession QuizSession, ttl time.Duration) error {
+	return r.DB.SetObjectWithTTL(ctx, quizSessionKey(playerID, quizSession.ID), quizSession, ttl)
+}
+
+func (r *Repository) GetSession(ctx context.Context, quizID, id string) (Session, error) {
+	session := Session{}
+	err := r.DB.GetObject(ctx, sessionKey(quizID, id), &session)
+	return session, err
+}
+
+func (r *Repository) SetSession(ctx context.Context, session Session) error {
+	return r.DB.SetObject(ctx, sessionKey(session.QuizID, session.ID), session)
+}
+
+// AddSession stores a session unless the player already has one on the quiz.
+// It returns whether the session was stored.
+func (r *Repository) AddSession(ctx context.Context, session Session) (bool, error) {
+	return r.DB.SetObjectIfAbsent(ctx, sessionKey(session.QuizID, session.ID), session)
+}
+
+// SetSessionWithTTL stores a session expiring after ttl, along with its practice quiz.
+func (r *Repository) SetSessionWithTTL(ctx context.Context, session Session, ttl time.Duration) error {
+	return r.DB.SetObjectWithTTL(ctx, sessionKey(session.QuizID, session.ID), session, ttl)
+}
+
+// GetCover retrieves the album cover downloaded for a quiz in cover mode.
+func (r *Repository) GetCover(ctx context.Context, quizID string) (coverImage, error) {
+	cover := coverImage{}
+	err := r.DB.GetObject(ctx, coverKey(quizID), &cover)
+	return cover, err
+}
+
+// SetCover stores the album cover of a quiz in cover mode, expiring
+// after ttl along with its practice quiz, or never when ttl is 0.
+func (r *Repository) SetCover(ctx context.Context, quizID string, cover coverImage, ttl time.Duration) error {
+	log.Printf("Setting cover with key: %s", coverKey(quizID))
+	if ttl == 0 {
+		return r.DB.SetObject(ctx, coverKey(quizID), cover)
+	}
+	return r.DB.SetObjectWithTTL(ctx, coverKey(quizID), cover, ttl)
+}
+
+// GetPreview retrieves the audio preview downloaded for a quiz.
+func (r *Repository) GetPreview(ctx context.Context, quizID string) (previewAudio, error) {
+	preview := previewAudio{}
+	err := r.DB.GetObject(ctx, previewKey(quizID), &preview)
+	return preview, err
+}
+
+// SetPreview stores the audio preview of a quiz, expiring after ttl.
+func (r *Repository) SetPreview(ctx context.Context, quizID string, preview previewAudio, ttl time.Duration) error {
+	log.Printf("Setting audio preview with key: %s", previewKey(quizID))
+	return r.DB.SetObjectWithTTL(ctx, previewKey(quizID), preview, ttl)
+}
+
+// GetStats retrieves the stats of a player on the daily quizzes of an edition.
+func (r *Repository) GetStats(ctx context.Context, playerID string, edition Edition) (PlayerStats, error) {
+	stats := PlayerStats{}
+	err := r.DB.GetObject(ctx, statsKey(playerID, edition), &stats)
+	return stats, err
+}
+
+// SetStats stores the stats of a player on the daily quizzes of an edition.
+func (r *Repository) SetStats(ctx context.Context, playerID string, edition Edition, stats PlayerStats) error {
+	return r.DB.SetObject(ctx, statsKey(playerID, edition), stats)
+}
+
+// AddPlayer records that a player started a quiz, keeping the first start time.
+func (r *Repository) AddPlayer(ctx context.Context, quizID, playerID string, startedAt time.Time) error {
+	_, err := r.DB.SortedSetAdd(ctx, quizStatsKey(quizID, "players"), playerID, float64(startedAt.UnixMilli()))
+	return err
+}
+
+// CountPlayers returns the number of players who started a quiz.
+func (r *Repository) CountPlayers(ctx context.Context, quizID string) (int64, error) {
+	return r.DB.SortedSetCount(ctx, quizStatsKey(quizID, "players"))
+}
+
+// AddSolve records the leaderboard score of a player who solved a quiz,
+// returning whether it's the first solve of the player.
+func (r *Repository) AddSolve(ctx context.Context, quizID, playerID string, score float64) (bool, error) {
+	return r.DB.SortedSetAdd(ctx, quizStatsKey(quizID, "solves"), playerID, score)
+}
+
+// AddUnrankedSolve records a player who solved a quiz without being timed, left out
+// of the leaderboard, returning whether it's the first solve of the player.
+func (r *Repository) AddUnrankedSolve(ctx context.Context, quizID, playerID string) (bool, error) {
+	return r.DB.SortedSetAdd(ctx, quizStatsKey(quizID, "unranked"), playerID, 0)
+}
+
+// CountSolves returns the number of players who solved a quiz, ranked or not.
+func (r *Repository) CountSolves(ctx context.Context, quizID string) (int64, error) {
+	ranked, err := r.DB.SortedSetCount(ctx, quizStatsKey(quizID, "solves"))
+	if err != nil {
+		return 0, err
+	}
+	unranked, err := r.DB.SortedSetCount(ctx, quizStatsKey(quizID, "unranked"))
+	return ranked + unranked, err
+}
+
+// GetLeaderboard returns the best leaderboard scores of a quiz.
+func (r *Repository) GetLeaderboard(ctx context.Context, quizID string, limit int) ([]db.ScoredMember, error) {
+	return r.DB.SortedSetRange(ctx, quizStatsKey(quizID, "solves"), 0, int64(limit)-1)
+}
+
+// AddSolveAttempts adds the attempts a player needed to solve a quiz to its total.
+func (r *Repository) AddSolveAttempts(ctx context.Context, quizID string, attempts int) error {
+	_, err := r.DB.IncrBy(ctx, quizStatsKey(quizID, "attempts"), int64(attempts))
+	return err
+}
+
+// GetSolveAttempts returns the total attempts of the players who solved a quiz.
+func (r *Repository) GetSolveAttempts(ctx context.Context, quizID string) (int64, error) {
+	return r.DB.GetCounter(ctx, quizStatsKey(quizID, "attempts"))
+}
+
+func dailyQuizKey(edition Edition, date time.Time) string {
+	return "quiz:" + edition.QuizID(date)
+}
+
+func practiceQuizKey(id string) string {
+	return "quiz:" + id
+}
+
+func customQuizKey(code string) string {
+	return "custom:" + code
+}
+
+func customRoundKey(id string) string {
+	return "quiz:" + id
+}
+
+func quizStatsKey(quizID, stat string) string {
+	return "stats:quiz:" + quizID + ":" + stat
+}
+
+func statsKey(playerID string, edition Edition) string {
+	key := "stats:" + playerID + ":" + edition.Category + ":" + edition.Difficulty
+	if edition.Mode != "" {
+		key += ":" + edition.Mode
+	}
+	return key
+}
+
+func coverKey(quizID string) string {
+	return "cover:" + quizID
+}
+
+func previewKey(quizID string) string {
+	return "audio:" + quizID
+}
+
+// LockQuizSession acquires the lock to serve the next round of a quiz session of a player.
+func (r *Repository) LockQuizSession(ctx context.Context, playerID, id string, ttl time.Duration) (db.UnlockFunc, error) {
+	return r.DB.Lock(ctx, "lock:"+quizSessionKey(playerID, id), ttl)
+}
+
+func quizSessionKey(playerID, id string) string {
+	return "quizsession:" + playerID + ":" + id
+}
+
+func sessionKey(quizID, id string) string {
+	return "session:" + quizID + ":" + id
+}
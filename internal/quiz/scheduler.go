@@ -0,0 +1,74 @@
+package quiz
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+)
+
+var (
+	bufferedDays       = expvar.NewInt("quiz_buffered_days")
+	generationFailures = expvar.NewInt("quiz_generation_failures")
+)
+
+// Scheduler generates the daily quizzes ahead of time in the background.
+type Scheduler struct {
+	service  Service
+	days     int           // how many days, counting today, are generated ahead
+	minDays  int           // buffer size below which an alert is logged
+	interval time.Duration // how often the buffer is refilled
+}
+
+// NewScheduler creates a new Scheduler.
+//
+// Parameters:
+//   - service: The quiz service used to generate the quizzes.
+//   - days: How many days, counting today, should always have a quiz ready.
+//   - minDays: The buffer size below which an alert is logged.
+//   - interval: How often the buffer is refilled.
+//
+// Returns:
+//   - A new quiz Scheduler.
+func NewScheduler(service Service, days, minDays int, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		service:  service,
+		days:     days,
+		minDays:  minDays,
+		interval: interval,
+	}
+}
+
+// Run fills the quiz buffer right away and then on every interval,
+// until the context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.fill(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fill generates the missing quizzes of the buffer and
+// alerts when fewer days than expected are ready.
+func (s *Scheduler) fill(ctx context.Context) {
+	buffered, err := s.service.FillBuffer(ctx, s.days)
+	bufferedDays.Set(int64(buffered))
+	if err != nil {
+		generationFailures.Add(1)
+		log.Printf("Error generating quizzes ahead of time: %v", err)
+	}
+
+	if buffered < s.minDays {
+		log.Printf("ALERT: only %d days of quizzes are ready, expected at least %d", buffered, s.minDays)
+		return
+	}
+	log.Printf("%d days of quizzes are ready", buffered)
+}
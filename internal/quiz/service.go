@@ -3,149 +3,1043 @@
 package quiz
 
 import (
+	"backendProject/internal/db"
 	"backendProject/internal/spotify"
 	"context"
-	"fmt"
+	"errors"
+	"hash/fnv"
 	"log"
 	"math/rand/v2"
+	"net/http"
+	"slices"
 	"time"
 )
 
+const (
+	generationLockTTL      = 2 * time.Minute // upper bound of a quiz generation, in case the instance holding the lock dies
+	generationPollInterval = 500 * time.Millisecond
+	downloadTimeout        = 10 * time.Second // of the album covers and audio previews
+	// defaultSeed lets the date alone drive the generation of the daily quizzes,
+	// so every instance and restart generates the same quiz for the same day.
+	defaultSeed uint64 = 0x5eed_d41a
+	// GeneralCategory names the general quiz, which has no category, in a BufferFilter.
+	GeneralCategory = "general"
+)
+
+// BufferFilter limits the daily quizzes generated ahead of time to some categories,
+// difficulties and modes, the others being generated when first requested. An empty
+// list doesn't limit anything.
+type BufferFilter struct {
+	Categories   []string // category IDs, GeneralCategory for the general quiz
+	Difficulties []string
+	Modes        []string
+}
+
+// matches tells whether the daily quizzes of an edition are generated ahead of time.
+func (f BufferFilter) matches(edition Edition) bool {
+	category := edition.Category
+	if category == "" {
+		category = GeneralCategory
+	}
+	return (len(f.Categories) == 0 || slices.Contains(f.Categories, category)) &&
+		(len(f.Difficulties) == 0 || slices.Contains(f.Difficulties, edition.difficulty())) &&
+		(len(f.Modes) == 0 || slices.Contains(f.Modes, edition.mode()))
+}
+
 type service struct {
 	repository     *Repository
 	spotifyService spotify.Service
+	location       *time.Location   // timezone where the daily quiz rolls over at midnight
+	now            func() time.Time // clock used to tell which day it is
+	seed           uint64           // combined with the quiz date to seed the generation
+	pickers        []TrackPicker    // strategies to pick the quiz track, in order of preference
+	categories     []Category       // themed daily quizzes generated alongside the general one
+	modes          []string         // alternate game modes having their own daily quizzes
+	practiceTTL    time.Duration    // how long a practice quiz can be played
+	bufferFilter   BufferFilter     // the daily quizzes generated ahead of time
+	shareTitle     string           // name of the game in the shared results
+	generations    flightGroup[Quiz]
+	suggestions    suggestionCache
+	autocompletes  flightGroup[[]Suggestion]
+	previews       flightGroup[previewAudio]
+	matcher        matcher      // scores guessed titles against the answer's
+	filterExplicit bool         // whether quizzes on explicit tracks are rejected
+	httpClient     *http.Client // downloads the album covers and audio previews
+}
+
+// Option configures optional settings of the quiz service.
+type Option func(*service)
+
+// WithLocation sets the timezone where the daily quiz rolls over. Defaults to UTC.
+func WithLocation(location *time.Location) Option {
+	return func(s *service) {
+		s.location = location
+	}
 }
 
-func NewService(repository *Repository, spotifyService spotify.Service) *service {
-	return &service{
+// WithClock sets the function used to get the current time. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *service) {
+		s.now = now
+	}
+}
+
+// WithSeed sets the seed combined with the quiz date to generate the daily quizzes,
+// so the same seed and date always produce the same quiz given the same Spotify
+// responses. Defaults to a fixed seed, so the date alone drives the generation.
+func WithSeed(seed uint64) Option {
+	return func(s *service) {
+		s.seed = seed
+	}
+}
+
+// WithTrackPickers sets the strategies used to pick the quiz track, each one is
+// used when the previous ones can't find a track. Defaults to the recommendations
+// picker falling back to the search picker.
+func WithTrackPickers(pickers ...TrackPicker) Option {
+	return func(s *service) {
+		s.pickers = pickers
+	}
+}
+
+// WithCategories sets the themed quiz categories, each one having its own daily quiz.
+func WithCategories(categories ...Category) Option {
+	return func(s *service) {
+		s.categories = categories
+	}
+}
+
+// WithModes enables alternate game modes, each one having its own daily quizzes
+// in every category and difficulty. The classic mode is always enabled.
+func WithModes(modes ...string) Option {
+	return func(s *service) {
+		s.modes = modes
+	}
+}
+
+// WithBufferFilter limits the daily quizzes generated ahead of time by FillBuffer,
+// so only the popular ones cost Spotify requests every day. The others are generated
+// on their first request. Defaults to every category, difficulty and mode.
+func WithBufferFilter(filter BufferFilter) Option {
+	return func(s *service) {
+		s.bufferFilter = filter
+	}
+}
+
+// WithShareTitle sets the name of the game heading the shared results
+// and their cards. Defaults to the name of the project, Spotifydle.
+func WithShareTitle(title string) Option {
+	return func(s *service) {
+		s.shareTitle = title
+	}
+}
+
+// WithExplicitFilter rejects the generated quizzes whose track is explicit,
+// generating another one instead. Explicit tracks are allowed by default.
+func WithExplicitFilter(enabled bool) Option {
+	return func(s *service) {
+		s.filterExplicit = enabled
+	}
+}
+
+// WithPracticeTTL sets how long a practice quiz can be played before it's deleted. Defaults to 2 hours.
+func WithPracticeTTL(ttl time.Duration) Option {
+	return func(s *service) {
+		s.practiceTTL = ttl
+	}
+}
+
+// WithMatchThresholds sets the similarity, from 0 to 1, a guessed title needs to be
+// correct or close to the answer's once both are normalized. Defaults to 0.9 and 0.7.
+func WithMatchThresholds(correct, close float64) Option {
+	return func(s *service) {
+		s.matcher = matcher{correct: correct, close: close}
+	}
+}
+
+func NewService(repository *Repository, spotifyService spotify.Service, opts ...Option) *service {
+	s := &service{
 		spotifyService: spotifyService,
 		repository:     repository,
+		location:       time.UTC,
+		now:            time.Now,
+		seed:           defaultSeed,
+		practiceTTL:    defaultPracticeTTL,
+		shareTitle:     defaultShareTitle,
+		matcher:        matcher{correct: defaultCorrectThreshold, close: defaultCloseThreshold},
+		httpClient:     &http.Client{Timeout: downloadTimeout},
+		pickers: []TrackPicker{
+			&recommendationsPicker{spotifyService: spotifyService},
+			&searchPicker{spotifyService: spotifyService},
+		},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// today returns the current time in the quiz timezone.
+func (s *service) today() time.Time {
+	return s.now().In(s.location)
+}
+
+// Categories returns the themed quiz categories.
+func (s *service) Categories() []Category {
+	return s.categories
+}
+
+// category returns the category of an edition, constrained to the popularity range
+// of its difficulty. The general edition has an empty category without other constraints.
+// The mode of the edition must be enabled.
+func (s *service) category(edition Edition) (Category, error) {
+	if edition.Mode != "" && !slices.Contains(s.modes, edition.Mode) {
+		return Category{}, ErrModeNotFound
+	}
+
+	level, ok := difficultyLevels[edition.difficulty()]
+	if !ok {
+		return Category{}, ErrDifficultyNotFound
+	}
+
+	category := Category{}
+	if edition.Category != "" {
+		i := slices.IndexFunc(s.categories, func(c Category) bool { return c.ID == edition.Category })
+		if i == -1 {
+			return Category{}, ErrCategoryNotFound
+		}
+		category = s.categories[i]
 	}
+
+	category.MinPopularity = level.minPopularity
+	category.MaxPopularity = level.maxPopularity
+	return category, nil
+}
+
+// editions returns every daily quiz series, starting with the general one,
+// with each category on every difficulty in every enabled mode.
+func (s *service) editions() []Edition {
+	categories := []string{""}
+	for _, category := range s.categories {
+		categories = append(categories, category.ID)
+	}
+	modes := append([]string{ModeClassic}, s.modes...)
+
+	editions := []Edition{}
+	for _, mode := range modes {
+		for _, category := range categories {
+			for _, difficulty := range Difficulties {
+				editions = append(editions, NewEdition(category, difficulty).WithMode(mode))
+			}
+		}
+	}
+	return editions
+}
+
+// TodaysQuizID returns the ID of today's daily quiz of the given edition.
+func (s *service) TodaysQuizID(edition Edition) string {
+	return edition.QuizID(s.today())
+}
+
+// randForQuiz returns the random source used to generate the quiz with the given ID.
+func (s *service) randForQuiz(id string) *rand.Rand {
+	hash := fnv.New64a()
+	hash.Write([]byte(id))
+	return rand.New(rand.NewPCG(s.seed, hash.Sum64()))
+}
+
+// NextRollover returns the moment today's quiz is replaced,
+// which is the next midnight in the quiz timezone.
+func (s *service) NextRollover() time.Time {
+	year, month, day := s.today().Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, s.location)
 }
 
 // GetTodaysQuiz generates a new quiz if one hasn't been created today
-// or returns the already generated quiz. It searches for a random song
-// in Spotify's API and maps the data to a Quiz object.
+// or returns the already generated quiz.
+//
+// Parameters:
+//   - edition: The daily quiz series, the zero value being the general quiz.
 //
 // Returns:
 //   - A Quiz object containing the generated quiz data.
-//   - An error if the quiz generation fails.
-func (s *service) GetTodaysQuiz(ctx context.Context) (Quiz, error) {
+//   - An error if the category doesn't exist or the quiz generation fails.
+func (s *service) GetTodaysQuiz(ctx context.Context, edition Edition) (Quiz, error) {
+	if _, err := s.category(edition); err != nil {
+		return Quiz{}, err
+	}
+
+	today := s.today()
+	id := edition.QuizID(today)
+
 	// early return if quiz was already generated today
-	todaysQuiz, err := s.repository.GetQuiz(ctx, "quiz")
+	todaysQuiz, err := s.repository.GetQuizByDate(ctx, edition, today)
 	if err != nil {
 		log.Printf("Error getting today's quiz: %v", err)
 		return Quiz{}, err
 	}
 
-	if !todaysQuiz.CreatedAt.IsZero() && time.Since(todaysQuiz.CreatedAt) < 24*time.Hour {
+	if !todaysQuiz.CreatedAt.IsZero() {
 		log.Printf("Returning already generated quiz created at: %v", todaysQuiz.CreatedAt)
 		return todaysQuiz, nil
 	}
 
-	randomTracks, err := s.spotifyService.RandomSearch("track")
+	// today's quiz should have been generated by the scheduler
+	log.Printf("Quiz %s was not generated ahead of time, generating it now", id)
+
+	// concurrent requests share a single generation
+	return s.generations.Do(id, func() (Quiz, error) {
+		return s.generateDailyQuiz(context.WithoutCancel(ctx), edition, today)
+	})
+}
+
+// FillBuffer makes sure the daily quizzes of every edition matching the buffer filter, from
+// today up to the given number of days ahead, are generated so requests never have to wait
+// for Spotify. The quizzes of the other editions are generated when first requested.
+//
+// Parameters:
+//   - days: How many days, counting today, should have a quiz ready.
+//
+// Returns:
+//   - The smallest number of consecutive days, starting today, that have a quiz ready in an edition.
+//   - An error if a quiz generation fails.
+func (s *service) FillBuffer(ctx context.Context, days int) (int, error) {
+	today := s.today()
+
+	minBuffered := days
+	var errs []error
+	for _, edition := range s.editions() {
+		if !s.bufferFilter.matches(edition) {
+			continue
+		}
+		buffered := 0
+		for i := 0; i < days; i++ {
+			date := today.AddDate(0, 0, i)
+			quiz, err := s.repository.GetQuizByDate(ctx, edition, date)
+			if err != nil {
+				log.Printf("Error getting quiz %s: %v", edition.QuizID(date), err)
+				errs = append(errs, err)
+				break
+			}
+
+			if quiz.CreatedAt.IsZero() {
+				_, err := s.generations.Do(edition.QuizID(date), func() (Quiz, error) {
+					return s.generateDailyQuiz(ctx, edition, date)
+				})
+				if err != nil {
+					errs = append(errs, err)
+					break
+				}
+			}
+			buffered++
+		}
+		minBuffered = min(minBuffered, buffered)
+	}
+	return minBuffered, errors.Join(errs...)
+}
+
+// generateDailyQuiz generates and stores the daily quiz of an edition on the given date.
+// A lock shared by every server instance makes sure only one of them generates the
+// quiz, while the others wait for it to be stored. As the lock expires in case its
+// holder dies, the quiz is only stored if no other instance stored one in the meantime,
+// the stored quiz being returned otherwise.
+//
+// Parameters:
+//   - edition: The daily quiz series.
+//   - date: The date of the daily quiz.
+//
+// Returns:
+//   - A Quiz object containing the generated quiz data.
+//   - An error if the quiz generation fails.
+func (s *service) generateDailyQuiz(ctx context.Context, edition Edition, date time.Time) (Quiz, error) {
+	id := edition.QuizID(date)
+	category, err := s.category(edition)
 	if err != nil {
-		log.Printf("Error searching for a random song: %v", err)
 		return Quiz{}, err
 	}
 
-	r := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
-	randomTrack := randomTracks.Tracks.Items[r.IntN(len(randomTracks.Tracks.Items))]
-
-	artistIDs := make([]string, 5)
-	for i, artist := range randomTrack.Album.Artists {
-		if i == 5 {
+	for {
+		unlock, err := s.repository.LockQuizByDate(ctx, edition, date, generationLockTTL)
+		if err == nil {
+			defer unlock(ctx)
 			break
 		}
+		if !errors.Is(err, db.ErrLockNotAcquired) {
+			log.Printf("Error locking quiz generation: %v", err)
+			return Quiz{}, err
+		}
 
-		artistIDs[i] = artist.ID
+		// another instance is generating the quiz, wait for it
+		log.Printf("Waiting for quiz %s to be generated by another instance", id)
+		time.Sleep(generationPollInterval)
+
+		quiz, err := s.repository.GetQuizByDate(ctx, edition, date)
+		if err != nil {
+			return Quiz{}, err
+		}
+		if !quiz.CreatedAt.IsZero() {
+			return quiz, nil
+		}
 	}
 
-	track, err := s.getRandomTrack(artistIDs, randomTrack.ID)
+	// the quiz might have been stored while the lock was being acquired
+	quiz, err := s.repository.GetQuizByDate(ctx, edition, date)
 	if err != nil {
-		switch err.(type) {
-		case *spotify.ErrRecommendationsEmpty:
-			log.Printf("No recommendations found with the current seed, retrying")
-			return s.GetTodaysQuiz(ctx) // retry if no recommendations are found with the current seed
-		default:
+		return Quiz{}, err
+	}
+	if !quiz.CreatedAt.IsZero() {
+		return quiz, nil
+	}
+
+	quiz, err = s.generateQuizForLevel(s.generator(edition), s.randForQuiz(id), category, difficultyLevels[edition.difficulty()])
+	if err != nil {
+		return Quiz{}, err
+	}
+
+	quiz.ID = id
+	quiz.Number = QuizNumber(date)
+	quiz.Category = edition.Category
+	quiz.Difficulty = edition.difficulty()
+	quiz.Mode = edition.mode()
+	var cover coverImage
+	if quiz.Mode == ModeCover {
+		cover, err = s.downloadCover(ctx, quiz.Album.Image)
+		if err != nil {
+			log.Printf("Error downloading cover of quiz %s: %v", quiz.ID, err)
 			return Quiz{}, err
 		}
 	}
+	stored, err := s.repository.AddQuizByDate(ctx, edition, date, quiz)
+	if err != nil {
+		log.Printf("Error setting quiz %s: %v", quiz.ID, err)
+		return Quiz{}, err
+	}
+	if !stored {
+		// the lock expired and another instance stored its quiz first
+		log.Printf("Quiz %s was already stored by another instance", id)
+		return s.repository.GetQuizByDate(ctx, edition, date)
+	}
+	if quiz.Mode == ModeCover {
+		if err := s.repository.SetCover(ctx, quiz.ID, cover, 0); err != nil {
+			log.Printf("Error setting cover of quiz %s: %v", quiz.ID, err)
+			return Quiz{}, err
+		}
+	}
+	if quiz.preview != nil {
+		s.cachePreview(ctx, quiz, quiz.preview)
+	}
 
-	recommmentedArtistIDs := make([]string, 5)
-	for i, artist := range track.Album.Artists {
-		if i == 5 {
-			break
+	return quiz, nil
+}
+
+// GetQuiz returns the quiz with the given ID. Today's quiz is generated if needed,
+// past quizzes are read from the archive and future ones are never found. Practice
+// quizzes are found until they expire.
+//
+// Parameters:
+//   - id: The ID of the daily or practice quiz. (2006-01-02, 2006-01-02:category, 2006-01-02:category:difficulty or mode-2006-01-02)
+//
+// Returns:
+//   - A Quiz object containing the quiz data.
+//   - An error if the ID is invalid, the quiz is not found or the quiz generation fails.
+func (s *service) GetQuiz(ctx context.Context, id string) (Quiz, error) {
+	if isPracticeID(id) {
+		return s.getPracticeQuiz(ctx, id)
+	}
+	if isCustomID(id) {
+		return s.getCustomRound(ctx, id)
+	}
+
+	date, edition, err := ParseQuizID(id)
+	if err != nil {
+		return Quiz{}, err
+	}
+	if _, err := s.category(edition); err != nil {
+		return Quiz{}, err
+	}
+
+	today := DailyID(s.today())
+	if DailyID(date) == today {
+		return s.GetTodaysQuiz(ctx, edition)
+	}
+	if DailyID(date) > today {
+		return Quiz{}, ErrQuizNotFound
+	}
+
+	quiz, err := s.repository.GetQuizByDate(ctx, edition, date)
+	if err != nil {
+		log.Printf("Error getting quiz %s: %v", id, err)
+		return Quiz{}, err
+	}
+	if quiz.CreatedAt.IsZero() {
+		return Quiz{}, ErrQuizNotFound
+	}
+	return quiz, nil
+}
+
+// GetAnswer returns the full data of a past quiz, today's answer is never revealed.
+//
+// Parameters:
+//   - id: The ID of the daily quiz. (2006-01-02, 2006-01-02:category or 2006-01-02:category:difficulty)
+//
+// Returns:
+//   - A Quiz object containing the answer.
+//   - An error if the quiz is not found or is still being played.
+func (s *service) GetAnswer(ctx context.Context, id string) (Quiz, error) {
+	date, _, err := ParseQuizID(id)
+	if err != nil {
+		return Quiz{}, err
+	}
+	if DailyID(date) >= DailyID(s.today()) {
+		return Quiz{}, ErrQuizNotRevealed
+	}
+	return s.GetQuiz(ctx, id)
+}
+
+// ListQuizzes returns a page of the quiz archive of an edition, from the most recent
+// to the oldest. Each page covers limit days, days without a quiz are skipped.
+//
+// Parameters:
+//   - edition: The daily quiz series.
+//   - page: The page number, starting at 1.
+//   - limit: The number of days in each page.
+//
+// Returns:
+//   - A slice of QuizSummary objects for the quizzes found in the page.
+//   - An error if the category doesn't exist or the archive can't be read.
+func (s *service) ListQuizzes(ctx context.Context, edition Edition, page, limit int) ([]QuizSummary, error) {
+	if _, err := s.category(edition); err != nil {
+		return nil, err
+	}
+
+	todaysID := s.TodaysQuizID(edition)
+	today, _ := ParseDailyID(DailyID(s.today()))
+
+	summaries := []QuizSummary{}
+	for i := (page - 1) * limit; i < page*limit; i++ {
+		date := today.AddDate(0, 0, -i)
+		quiz, err := s.repository.GetQuizByDate(ctx, edition, date)
+		if err != nil {
+			log.Printf("Error getting quiz from %v: %v", date, err)
+			return nil, err
 		}
+		if quiz.CreatedAt.IsZero() {
+			continue
+		}
+
+		summaries = append(summaries, QuizSummary{
+			ID:        edition.QuizID(date),
+			Number:    QuizNumber(date),
+			CreatedAt: quiz.CreatedAt,
+			Revealed:  edition.QuizID(date) != todaysID,
+		})
+	}
+	return summaries, nil
+}
 
-		recommmentedArtistIDs[i] = artist.ID
+// generateQuiz picks a random song using the configured track
+// pickers and maps the data to a new Quiz object.
+//
+// Parameters:
+//   - r: The random source driving every choice of the generation.
+//   - category: The category constraining the picked track.
+//
+// Returns:
+//   - A Quiz object containing the generated quiz data.
+//   - An error if the quiz generation fails.
+func (s *service) generateQuiz(r *rand.Rand, category Category) (Quiz, error) {
+	track, err := pickTrack(s.pickers, r, category)
+	if err != nil {
+		log.Printf("Error picking a track: %v", err)
+		return Quiz{}, err
 	}
 
-	artists, err := s.spotifyService.GetArtists(recommmentedArtistIDs)
+	artists, err := s.spotifyService.GetArtists(artistIDs(track.Album.Artists, 5))
 	if err != nil {
 		log.Printf("Error getting artists from random song: %v", err)
 		return Quiz{}, err
 	}
 
-	todaysQuiz = buildQuiz(track, artists.Artists)
-	err = s.repository.SetQuiz(ctx, "quiz", todaysQuiz)
+	quiz := buildQuiz(track, artists.Artists)
+	quiz.DifficultyScore = DifficultyScore(track, artists.Artists)
+
+	artistNames := make([]string, len(quiz.Artists))
+	for i, artist := range quiz.Artists {
+		artistNames[i] = artist.Name
+	}
+	log.Printf("Generated Quiz with Track: %s, Album: %s, Artists: %v", quiz.Track.Name, quiz.Album.Name, artistNames)
+
+	return quiz, nil
+}
+
+// quizGenerator generates a quiz with a track picked in a category.
+type quizGenerator func(r *rand.Rand, category Category) (Quiz, error)
+
+// generator returns the generator of the quizzes of an edition,
+// the artist, album and audio modes having their own.
+func (s *service) generator(edition Edition) quizGenerator {
+	switch edition.Mode {
+	case ModeArtist:
+		return s.generateArtistQuiz
+	case ModeAlbum:
+		return s.generateAlbumQuiz
+	case ModeAudio:
+		return s.generateAudioQuiz
+	default:
+		return s.generateQuiz
+	}
+}
+
+// generateQuizForLevel generates quizzes until one has a difficulty score in the range
+// of the level. The closest quiz is kept when every attempt misses the range, as the
+// tracks were already picked within the popularity range of the level.
+//
+// Parameters:
+//   - generate: The generator of the quizzes of the edition.
+//   - r: The random source driving every choice of the generation.
+//   - category: The category constraining the picked track.
+//   - level: The difficulty level the quiz must match.
+//
+// Returns:
+//   - A Quiz object containing the generated quiz data.
+//   - An error if the quiz generation fails.
+func (s *service) generateQuizForLevel(generate quizGenerator, r *rand.Rand, category Category, level difficultyLevel) (Quiz, error) {
+	var closest Quiz
+	closestDistance := -1
+	for attempts := 0; attempts < maxDifficultyAttempts; attempts++ {
+		quiz, err := s.generateValidQuiz(generate, r, category)
+		if err != nil {
+			return Quiz{}, err
+		}
+
+		distance := level.distance(quiz.DifficultyScore)
+		if distance == 0 {
+			return quiz, nil
+		}
+		if closestDistance == -1 || distance < closestDistance {
+			closest, closestDistance = quiz, distance
+		}
+		log.Printf("Quiz difficulty score %d is outside of %d-%d, retrying", quiz.DifficultyScore, level.minScore, level.maxScore)
+	}
+
+	log.Printf("No quiz matched the difficulty after %d attempts, keeping the closest one", maxDifficultyAttempts)
+	return closest, nil
+}
+
+// GetClues returns the clues of a quiz unlocked by a session. The session of a player
+// served today's quiz for the first time is stored, so the leaderboard measures the
+// time to solve it from then.
+//
+// Parameters:
+//   - quizID: The ID of the quiz being played.
+//   - sessionID: The ID of the player's session.
+//
+// Returns:
+//   - A Clues object containing only the unlocked hints.
+//   - An error if the quiz or the session can't be retrieved.
+func (s *service) GetClues(ctx context.Context, quizID, sessionID string) (Clues, error) {
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return Clues{}, err
+	}
+
+	session, err := s.getSession(ctx, sessionID, quiz)
+	if err != nil {
+		return Clues{}, err
+	}
+	if len(session.Guesses) == 0 && !session.Finished() && quiz.ID == s.TodaysQuizID(quiz.Edition()) {
+		session.Served = true
+		if _, err := s.repository.AddSession(ctx, session); err != nil {
+			log.Printf("Error starting session: %v", err)
+		}
+	}
+
+	return s.clues(quiz, session), nil
+}
+
+// clues returns the clues of a quiz unlocked by a session, telling when
+// the quiz rolls over if it's today's quiz.
+func (s *service) clues(quiz Quiz, session Session) Clues {
+	clues := quiz.Clues(session)
+	if quiz.ID == s.TodaysQuizID(quiz.Edition()) {
+		nextRollover := s.NextRollover()
+		clues.NextRollover = &nextRollover
+	}
+	return clues
+}
+
+// Guess checks a guess against a quiz and records it in the player's
+// session, unlocking the next hint when it's wrong. The guessed track is looked
+// up on Spotify so every attribute can be compared on the server, without the
+// answer ever being sent to the client.
+//
+// Parameters:
+//   - quizID: The ID of the quiz being played.
+//   - sessionID: The ID of the player's session.
+//   - guess: The guessed track ID or track name.
+//
+// Returns:
+//   - A GuessResponse object containing the feedback for each attribute and the unlocked clues.
+//   - An error if the guess is empty, the quiz was already solved, the track is not found or the request fails.
+func (s *service) Guess(ctx context.Context, quizID, sessionID string, guess Guess) (GuessResponse, error) {
+	if guess == (Guess{}) {
+		return GuessResponse{}, ErrEmptyGuess
+	}
+
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return GuessResponse{}, err
+	}
+
+	session, err := s.getSession(ctx, sessionID, quiz)
+	if err != nil {
+		return GuessResponse{}, err
+	}
+	if session.Finished() {
+		return GuessResponse{}, ErrQuizSolved
+	}
+
+	check := s.checkGuess
+	switch quiz.Mode {
+	case ModeArtist:
+		check = s.checkArtistGuess
+	case ModeAlbum:
+		check = s.checkAlbumGuess
+	}
+	result, err := check(quiz, guess)
+	if err != nil {
+		return GuessResponse{}, err
+	}
+
+	return s.addGuess(ctx, quiz, session, result)
+}
+
+// addGuess records a guess, or a skip, in the player's session along with the stats.
+// Running out of tries in audio mode ends the session as if it was given up.
+//
+// Parameters:
+//   - quiz: The quiz being played.
+//   - session: The player's session, not finished yet.
+//   - result: The feedback of the guess.
+//
+// Returns:
+//   - A GuessResponse object containing the feedback and the unlocked clues.
+//   - An error if the session can't be saved.
+func (s *service) addGuess(ctx context.Context, quiz Quiz, session Session, result GuessResult) (GuessResponse, error) {
+	session.Guesses = append(session.Guesses, result)
+	session.Solved = result.Correct
+	session.GaveUp = !session.Solved && quiz.outOfTries(session)
+	if session.Finished() {
+		finishedAt := s.now()
+		session.FinishedAt = &finishedAt
+	}
+	err := s.setSession(ctx, quiz, session)
+	if err != nil {
+		log.Printf("Error saving session: %v", err)
+		return GuessResponse{}, err
+	}
+
+	// the guess is already saved, stats are caught up on the next guess of the day
+	if err := s.recordGame(ctx, quiz, session); err != nil {
+		log.Printf("Error recording stats of player %s: %v", session.ID, err)
+	}
+	if err := s.recordQuizStats(ctx, quiz, session); err != nil {
+		log.Printf("Error recording stats of quiz %s: %v", quiz.ID, err)
+	}
+	if err := s.recordRound(ctx, quiz, session); err != nil {
+		log.Printf("Error recording round %s: %v", quiz.ID, err)
+	}
+
+	return GuessResponse{
+		GuessResult: result,
+		Clues:       s.clues(quiz, session),
+	}, nil
+}
+
+// GiveUp ends the session of a player on a quiz without solving it, revealing the
+// answer to the player. A daily quiz given up counts as a loss in the player's stats.
+//
+// Parameters:
+//   - quizID: The ID of the quiz being played.
+//   - sessionID: The ID of the player's session.
+//
+// Returns:
+//   - A Quiz object containing the answer.
+//   - An error if the quiz or the session can't be retrieved, or the quiz is already finished.
+func (s *service) GiveUp(ctx context.Context, quizID, sessionID string) (Quiz, error) {
+	quiz, err := s.GetQuiz(ctx, quizID)
 	if err != nil {
-		log.Printf("Error setting today's quiz: %v", err)
 		return Quiz{}, err
 	}
 
-	artistNames := make([]string, len(todaysQuiz.Artists))
-	for i, artist := range todaysQuiz.Artists {
-		artistNames[i] = artist.Name
+	session, err := s.getSession(ctx, sessionID, quiz)
+	if err != nil {
+		return Quiz{}, err
+	}
+	if session.Finished() {
+		return Quiz{}, ErrQuizSolved
 	}
-	log.Printf("Generated Quiz with Track: %s, Album: %s, Artists: %v", todaysQuiz.Track.Name, todaysQuiz.Album.Name, artistNames)
 
-	return todaysQuiz, nil
+	finishedAt := s.now()
+	session.GaveUp = true
+	session.FinishedAt = &finishedAt
+	err = s.setSession(ctx, quiz, session)
+	if err != nil {
+		log.Printf("Error saving session: %v", err)
+		return Quiz{}, err
+	}
+
+	if err := s.recordGame(ctx, quiz, session); err != nil {
+		log.Printf("Error recording stats of player %s: %v", session.ID, err)
+	}
+	if err := s.recordQuizStats(ctx, quiz, session); err != nil {
+		log.Printf("Error recording stats of quiz %s: %v", quiz.ID, err)
+	}
+	if err := s.recordRound(ctx, quiz, session); err != nil {
+		log.Printf("Error recording round %s: %v", quiz.ID, err)
+	}
+	return quiz, nil
 }
 
-// getRandomTrack retrieves a random recommended track from Spotify's API based on a list of artist IDs and a random track ID.
+// getSession retrieves the session of a player for the given quiz,
+// starting a new one if it doesn't exist.
 //
 // Parameters:
-//   - artistIDs: A slice of artist IDs to use as seed artists.
-//   - randomTrackID: A random track ID to use as a seed track.
+//   - sessionID: The ID of the player's session.
+//   - quiz: The quiz being played.
 //
 // Returns:
-//   - A Spotify track object containing the random recommended track data.
-//   - An error if the request fails.
-func (s *service) getRandomTrack(artistIDs []string, randomTrackID string) (spotify.Track, error) {
-	r := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
-	attempts := 0
-	maxAttempts := 10
-	for attempts < maxAttempts {
-		// limit the number of seeds to 5. 4 artists and the random track
-		seedArtists := artistIDs
-		if len(seedArtists) > 4 {
-			seedArtists = seedArtists[:4]
+//   - A Session object containing the player's progress on the quiz.
+//   - An error if the session can't be retrieved.
+func (s *service) getSession(ctx context.Context, sessionID string, quiz Quiz) (Session, error) {
+	// practice quizzes can only be played by the session that created them
+	if quiz.SessionID != "" && quiz.SessionID != sessionID {
+		return Session{}, ErrQuizNotFound
+	}
+
+	session, err := s.repository.GetSession(ctx, quiz.ID, sessionID)
+	if err != nil {
+		log.Printf("Error getting session: %v", err)
+		return Session{}, err
+	}
+
+	if session.ID == "" {
+		session = Session{
+			ID:        sessionID,
+			QuizID:    quiz.ID,
+			Guesses:   []GuessResult{},
+			StartedAt: s.now(),
 		}
+	}
+	return session, nil
+}
 
-		recommendedTracks, err := s.spotifyService.GetRecommendations(seedArtists, nil, []string{randomTrackID}, 80)
+// setSession stores the session of a player, expiring along with the quiz when it's a practice one.
+func (s *service) setSession(ctx context.Context, quiz Quiz, session Session) error {
+	if quiz.ExpiresAt == nil {
+		return s.repository.SetSession(ctx, session)
+	}
+	return s.repository.SetSessionWithTTL(ctx, session, max(quiz.ExpiresAt.Sub(s.now()), time.Second))
+}
+
+// findTrack retrieves the guessed track from Spotify's API, either by its ID
+// or by the first search result for its name.
+//
+// Parameters:
+//   - guess: The guessed track ID or track name.
+//
+// Returns:
+//   - A Spotify track object containing the guessed track data.
+//   - An error if the track is not found or the request fails.
+func (s *service) findTrack(guess Guess) (spotify.Track, error) {
+	if guess.TrackID != "" {
+		tracks, err := s.spotifyService.GetTracks([]string{guess.TrackID})
 		if err != nil {
-			log.Printf("Error getting recommendations from random song: %v", err)
-			return spotify.Track{}, err
+			log.Printf("Error getting guessed track: %v", err)
+			return spotify.Track{}, ErrTrackNotFound
 		}
-		if len(recommendedTracks.Tracks) == 0 {
-			return spotify.Track{}, &spotify.ErrRecommendationsEmpty{Message: "no recommendations found"}
+		if len(tracks.Tracks) == 0 || tracks.Tracks[0].ID == "" {
+			return spotify.Track{}, ErrTrackNotFound
 		}
+		return tracks.Tracks[0], nil
+	}
 
-		for j := 0; j < 10; j++ {
-			recommendedTrack := recommendedTracks.Tracks[r.IntN(len(recommendedTracks.Tracks))]
+	search, err := s.spotifyService.Search(guess.TrackName, "track")
+	if err != nil {
+		log.Printf("Error searching for guessed track: %v", err)
+		return spotify.Track{}, err
+	}
+	if len(search.Tracks.Items) == 0 {
+		return spotify.Track{}, ErrTrackNotFound
+	}
+	return search.Tracks.Items[0], nil
+}
 
-			// print the entire recommendedTrack object for inspection
-			fmt.Printf("Recommended Track: %+v\n", recommendedTrack)
+// checkGuess compares a guess with the answer of a quiz. Titles are matched loosely,
+// so a typed title or another release of the answer, like a remaster, is still
+// correct, and titles almost matching the answer's are reported as close.
+// In cover mode the answer is the album, so any of its tracks is correct
+// and typed titles are matched against the album name.
+//
+// Parameters:
+//   - quiz: The quiz holding the answer.
+//   - guess: The guessed track ID or typed title.
+//
+// Returns:
+//   - A GuessResult object containing the feedback for each attribute.
+//   - An error if the guessed track is not found or the request fails.
+func (s *service) checkGuess(quiz Quiz, guess Guess) (GuessResult, error) {
+	typed := guess.TrackID == ""
+	if typed && guess.TrackName == "" {
+		return GuessResult{}, ErrEmptyGuess
+	}
+	answer := quiz.Track.Name
+	if quiz.Mode == ModeCover {
+		answer = quiz.Album.Name
+	}
 
-			// return the first track found with a preview URL
-			if recommendedTrack.PreviewURL != "" {
-				return recommendedTrack, nil
-			}
+	// a typed title matching the answer's needs no lookup
+	titleMatch := s.matcher.match(guess.TrackName, answer)
+	if typed && titleMatch == matchCorrect {
+		return answerResult(quiz), nil
+	}
+
+	track, err := s.findTrack(guess)
+	if errors.Is(err, ErrTrackNotFound) && typed && titleMatch == matchClose {
+		// a close title with a typo might not be found at all
+		return GuessResult{TrackName: guess.TrackName, SharedGenres: []string{}, Close: true}, nil
+	}
+	if err != nil {
+		return GuessResult{}, err
+	}
+
+	artists, err := s.spotifyService.GetArtists(artistIDs(track.Album.Artists, 5))
+	if err != nil {
+		log.Printf("Error getting artists from guessed song: %v", err)
+		return GuessResult{}, err
+	}
+
+	result := compareGuess(quiz, track, artists.Artists)
+	if quiz.Mode == ModeCover {
+		result.Correct = result.SameAlbum
+	}
+	if result.Correct {
+		return result, nil
+	}
+
+	if !typed {
+		titleMatch = s.matcher.match(track.Name, answer)
+	}
+	switch titleMatch {
+	case matchCorrect:
+		// the same title by someone else is a cover, which is only close
+		result.Correct = result.SameArtist
+		result.Close = !result.SameArtist
+	case matchClose:
+		result.Close = true
+	}
+	return result, nil
+}
+
+// answerResult returns the feedback of a guess matching the answer of a quiz.
+func answerResult(quiz Quiz) GuessResult {
+	return GuessResult{
+		Correct:      true,
+		TrackID:      quiz.Track.ID,
+		TrackName:    quiz.Track.Name,
+		SameArtist:   true,
+		SameAlbum:    true,
+		SharedGenres: quiz.Genres(),
+		ReleaseYear:  YearEqual,
+	}
+}
+
+// compareGuess compares a guessed track and its artists against a quiz.
+//
+// Parameters:
+//   - quiz: The quiz holding the answer.
+//   - track: The guessed Spotify track.
+//   - artists: The artists of the guessed track.
+//
+// Returns:
+//   - A GuessResult object containing the feedback for each attribute.
+func compareGuess(quiz Quiz, track spotify.Track, artists []spotify.Artist) GuessResult {
+	quizArtists := make(map[string]bool)
+	for _, artist := range quiz.Artists {
+		quizArtists[artist.ID] = true
+	}
+
+	sameArtist := false
+	for _, artist := range track.Album.Artists {
+		if quizArtists[artist.ID] {
+			sameArtist = true
+			break
 		}
-		attempts++
 	}
-	return spotify.Track{}, fmt.Errorf("could not find a recommended track with a preview URL after %d attempts", maxAttempts)
+
+	quizGenres := make(map[string]bool)
+	for _, genre := range quiz.Genres() {
+		quizGenres[genre] = true
+	}
+
+	sharedGenres := []string{}
+	for _, genre := range (Quiz{Artists: mapArtists(artists)}).Genres() {
+		if quizGenres[genre] {
+			sharedGenres = append(sharedGenres, genre)
+		}
+	}
+
+	return GuessResult{
+		Correct:      track.ID == quiz.Track.ID,
+		TrackID:      track.ID,
+		TrackName:    track.Name,
+		SameArtist:   sameArtist,
+		SameAlbum:    track.Album.ID == quiz.Album.ID,
+		SharedGenres: sharedGenres,
+		ReleaseYear:  compareYears(releaseYear(quiz.Album.ReleaseDate), releaseYear(track.Album.ReleaseDate)),
+	}
+}
+
+// compareYears tells where the answer's year is relative to the guessed year.
+//
+// Parameters:
+//   - answer: The release year of the quiz answer.
+//   - guess: The release year of the guessed track.
+//
+// Returns:
+//   - YearHigher, YearLower or YearEqual.
+func compareYears(answer, guess string) string {
+	switch {
+	case answer > guess:
+		return YearHigher
+	case answer < guess:
+		return YearLower
+	default:
+		return YearEqual
+	}
+}
+
+// releaseYear extracts the year of a Spotify release date, which
+// can have a precision of year, month or day. (1975, 1975-09, 1975-09-12)
+func releaseYear(releaseDate string) string {
+	if len(releaseDate) < 4 {
+		return releaseDate
+	}
+	return releaseDate[:4]
+}
+
+// artistIDs returns the IDs of up to max artists, without blank or duplicate entries.
+func artistIDs(artists []spotify.SimplifiedArtist, max int) []string {
+	ids := make([]string, 0, max)
+	for _, artist := range artists {
+		if len(ids) == max {
+			break
+		}
+		if artist.ID != "" && !slices.Contains(ids, artist.ID) {
+			ids = append(ids, artist.ID)
+		}
+	}
+	return ids
 }
 
 // buildQuiz creates a Quiz object from a given Spotify track and a list
@@ -178,9 +1072,10 @@ func mapArtists(artists []spotify.Artist) []quizArtist {
 	mappedArtists := make([]quizArtist, len(artists))
 	for i, artist := range artists {
 		mappedArtists[i] = quizArtist{
-			ID:     artist.ID,
-			Name:   artist.Name,
-			Genres: artist.Genres,
+			ID:        artist.ID,
+			Name:      artist.Name,
+			Genres:    artist.Genres,
+			Followers: artist.Followers.Total,
 		}
 	}
 	return mappedArtists
@@ -192,14 +1087,20 @@ func mapArtists(artists []spotify.Artist) []quizArtist {
 //   - album: A Spotify album to be mapped.
 //
 // Returns:
-//   - A quizAlbum object containing the mapped album data.
+//   - A quizAlbum object containing the mapped album data, without an image when the album has none.
 func mapAlbum(album spotify.Album) quizAlbum {
-	return quizAlbum{
+	quizAlbum := quizAlbum{
 		ID:          album.ID,
 		Name:        album.Name,
-		Image:       album.Images[0].URL,
 		ReleaseDate: album.ReleaseDate,
+		AlbumType:   album.AlbumType,
+		TotalTracks: album.TotalTracks,
+		Label:       album.Label,
+	}
+	if len(album.Images) > 0 {
+		quizAlbum.Image = album.Images[0].URL
 	}
+	return quizAlbum
 }
 
 // mapTrack converts a Spotify track to a quizSong object.
@@ -214,5 +1115,6 @@ func mapTrack(track spotify.Track) quizSong {
 		ID:           track.ID,
 		Name:         track.Name,
 		AudioPreview: track.PreviewURL,
+		Explicit:     track.Explicit,
 	}
 }
@@ -3,10 +3,24 @@ package quiz
 import (
 	"backendProject/internal/db"
 	"backendProject/internal/spotify"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
 	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -30,7 +44,7 @@ func TestGetTodaysQuiz(t *testing.T) {
 	quizService := NewService(repo, spotifyService)
 
 	// Get today's quiz
-	quiz, err := quizService.GetTodaysQuiz(ctx)
+	quiz, err := quizService.GetTodaysQuiz(ctx, Edition{})
 	if err != nil {
 		t.Errorf("Error getting today's quiz")
 	}
@@ -68,13 +82,13 @@ func TestGetTodaysQuizTwice(t *testing.T) {
 	quizService := NewService(repo, spotifyService)
 
 	// Get today's quiz
-	quiz, err := quizService.GetTodaysQuiz(ctx)
+	quiz, err := quizService.GetTodaysQuiz(ctx, Edition{})
 	if err != nil {
 		log.Fatalf("error getting today's quiz")
 	}
 
 	// Get today's quiz again
-	quiz2, err := quizService.GetTodaysQuiz(ctx)
+	quiz2, err := quizService.GetTodaysQuiz(ctx, Edition{})
 	if err != nil {
 		log.Fatalf("error getting today's quiz")
 	}
@@ -103,7 +117,8 @@ func TestGetRandomTrack(t *testing.T) {
 	quizService := NewService(repo, spotifyService)
 
 	// Get a random track based on Wish You Were Here by pink floyd
-	track, err := quizService.getRandomTrack([]string{"0k17h0D3J5VfsdmQ1iZtE9"}, "6mFkJmJqdDVQ1REhVfGgd1")
+	picker := &recommendationsPicker{spotifyService: quizService.spotifyService}
+	track, err := picker.getRandomTrack(rand.New(rand.NewPCG(1, 2)), Category{}, []string{"0k17h0D3J5VfsdmQ1iZtE9"}, "6mFkJmJqdDVQ1REhVfGgd1")
 	if err != nil {
 		switch err.(type) {
 		case *spotify.ErrRecommendationsEmpty:
@@ -128,3 +143,2059 @@ func TestGetRandomTrack(t *testing.T) {
 		t.Errorf("Expected track to have a preview URL, got empty string")
 	}
 }
+
+// fakeSpotifyService is an offline spotify.Service backed by in memory data.
+type fakeSpotifyService struct {
+	tracks          map[string]spotify.Track
+	artists         map[string]spotify.Artist
+	albums          map[string]spotify.Album
+	playlists       map[string][]spotify.Track
+	recommendations []spotify.Track
+	noRecommended   bool          // whether recommendations are always empty
+	delay           time.Duration // how long random searches take
+	randomSearches  atomic.Int32
+	mu              sync.Mutex
+	queries         []url.Values // query parameters of every search
+}
+
+func newFakeSpotifyService(tracks []spotify.Track, artists []spotify.Artist) *fakeSpotifyService {
+	f := &fakeSpotifyService{
+		tracks:          make(map[string]spotify.Track),
+		artists:         make(map[string]spotify.Artist),
+		albums:          make(map[string]spotify.Album),
+		playlists:       make(map[string][]spotify.Track),
+		recommendations: tracks,
+	}
+	for _, track := range tracks {
+		f.tracks[track.ID] = track
+		f.albums[track.Album.ID] = track.Album
+	}
+	for _, artist := range artists {
+		f.artists[artist.ID] = artist
+	}
+	return f
+}
+
+func (f *fakeSpotifyService) GetAlbums(albumIds []string) (spotify.AlbumResponse, error) {
+	response := spotify.AlbumResponse{}
+	for _, id := range albumIds {
+		if album, ok := f.albums[id]; ok {
+			response.Albums = append(response.Albums, album)
+		}
+	}
+	return response, nil
+}
+
+func (f *fakeSpotifyService) GetTracks(trackIds []string) (spotify.TrackResponse, error) {
+	response := spotify.TrackResponse{}
+	for _, id := range trackIds {
+		if track, ok := f.tracks[id]; ok {
+			response.Tracks = append(response.Tracks, track)
+		}
+	}
+	return response, nil
+}
+
+func (f *fakeSpotifyService) GetArtists(artistIds []string) (spotify.ArtistResponse, error) {
+	response := spotify.ArtistResponse{}
+	for _, id := range artistIds {
+		if artist, ok := f.artists[id]; ok {
+			response.Artists = append(response.Artists, artist)
+		}
+	}
+	return response, nil
+}
+
+func (f *fakeSpotifyService) Search(query, queryType string, opts ...spotify.RequestOption) (spotify.SearchResponse, error) {
+	params := url.Values{"q": {query}}
+	for _, opt := range opts {
+		opt(params)
+	}
+	f.mu.Lock()
+	f.queries = append(f.queries, params)
+	f.mu.Unlock()
+
+	if strings.Contains(query, "%") {
+		return f.RandomSearch(queryType)
+	}
+
+	response := spotify.SearchResponse{}
+	for _, track := range f.recommendations {
+		if strings.Contains(strings.ToLower(track.Name), strings.ToLower(query)) {
+			response.Tracks.Items = append(response.Tracks.Items, track)
+		}
+	}
+	for _, artist := range f.artists {
+		if queryType == "artist" && strings.Contains(strings.ToLower(artist.Name), strings.ToLower(query)) {
+			response.Artists.Items = append(response.Artists.Items, artist)
+		}
+	}
+	for _, album := range f.albums {
+		if queryType == "album" && strings.Contains(strings.ToLower(album.Name), strings.ToLower(query)) {
+			response.Albums.Items = append(response.Albums.Items, album)
+		}
+	}
+	return response, nil
+}
+
+func (f *fakeSpotifyService) RandomSearch(queryType string) (spotify.SearchResponse, error) {
+	f.randomSearches.Add(1)
+	time.Sleep(f.delay)
+
+	response := spotify.SearchResponse{}
+	response.Tracks.Items = f.recommendations
+	return response, nil
+}
+
+func (f *fakeSpotifyService) GetRecommendations(seedArtists, seedGenres, seedTracks []string, popularity int, opts ...spotify.RequestOption) (spotify.RecommendationsResponse, error) {
+	if f.noRecommended {
+		return spotify.RecommendationsResponse{}, nil
+	}
+	return spotify.RecommendationsResponse{Tracks: f.recommendations}, nil
+}
+
+func (f *fakeSpotifyService) GetArtistTopTracks(artistID string, opts ...spotify.RequestOption) (spotify.TrackResponse, error) {
+	response := spotify.TrackResponse{}
+	for _, track := range f.recommendations {
+		if len(track.Album.Artists) > 0 && track.Album.Artists[0].ID == artistID {
+			response.Tracks = append(response.Tracks, track)
+		}
+	}
+	return response, nil
+}
+
+func (f *fakeSpotifyService) GetRelatedArtists(artistID string) (spotify.ArtistResponse, error) {
+	response := spotify.ArtistResponse{}
+	for id, artist := range f.artists {
+		if id != artistID {
+			response.Artists = append(response.Artists, artist)
+		}
+	}
+	slices.SortFunc(response.Artists, func(a, b spotify.Artist) int { return strings.Compare(a.ID, b.ID) })
+	return response, nil
+}
+
+func (f *fakeSpotifyService) GetPlaylistTracks(playlistID string, opts ...spotify.RequestOption) (spotify.TrackResponse, error) {
+	tracks, ok := f.playlists[playlistID]
+	if !ok {
+		return spotify.TrackResponse{}, spotify.ErrPlaylistNotFound
+	}
+	return spotify.TrackResponse{Tracks: tracks}, nil
+}
+
+func fakeTrack(id, name, albumID, releaseDate string, artistIDs ...string) spotify.Track {
+	track := spotify.Track{
+		ID:         id,
+		Name:       name,
+		PreviewURL: "https://p.scdn.co/mp3-preview/" + id,
+		Popularity: 85,
+		Album: spotify.Album{
+			ID:          albumID,
+			Name:        "Album " + albumID,
+			ReleaseDate: releaseDate,
+			Images: []struct {
+				URL string `json:"url"`
+			}{{URL: "https://i.scdn.co/image/" + albumID}},
+		},
+	}
+	for _, artistID := range artistIDs {
+		track.Album.Artists = append(track.Album.Artists, spotify.SimplifiedArtist{ID: artistID, Name: "Artist " + artistID})
+	}
+	return track
+}
+
+// fakeArtist returns an artist with a genre of its own when none is given, as quizzes need genres.
+func fakeArtist(id string, genres ...string) spotify.Artist {
+	if len(genres) == 0 {
+		genres = []string{id + " genre"}
+	}
+	artist := spotify.Artist{ID: id, Name: "Artist " + id, Genres: genres}
+	artist.Followers.Total = 1_000_000
+	return artist
+}
+
+func TestGuess(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+	repo := NewRepository(db)
+
+	answer := fakeTrack("answer", "Wish You Were Here", "wywh", "1975-09-12", "floyd")
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			answer,
+			fakeTrack("sibling", "Have a Cigar", "wywh", "1975-09-12", "floyd"),
+			fakeTrack("older", "Bohemian Rhapsody", "opera", "1975-10-31", "queen"),
+			fakeTrack("newer", "Karma Police", "okc", "1997", "radiohead"),
+		},
+		[]spotify.Artist{
+			fakeArtist("floyd", "art rock", "progressive rock"),
+			fakeArtist("queen", "classic rock", "glam rock"),
+			fakeArtist("radiohead", "art rock", "alternative rock"),
+		},
+	)
+	todaysQuiz := buildQuiz(answer, []spotify.Artist{spotifyService.artists["floyd"]})
+	todaysQuiz.ID = DailyID(time.Now().UTC())
+	repo.SetQuizByDate(ctx, Edition{}, time.Now().UTC(), todaysQuiz)
+	quizService := NewService(repo, spotifyService)
+
+	testCases := []struct {
+		name     string
+		given    Guess
+		expected GuessResult
+	}{
+		{"correct", Guess{TrackID: "answer"}, GuessResult{Correct: true, SameArtist: true, SameAlbum: true, SharedGenres: []string{"art rock", "progressive rock"}, ReleaseYear: YearEqual}},
+		{"same album", Guess{TrackID: "sibling"}, GuessResult{SameArtist: true, SameAlbum: true, SharedGenres: []string{"art rock", "progressive rock"}, ReleaseYear: YearEqual}},
+		{"by name", Guess{TrackName: "karma"}, GuessResult{SharedGenres: []string{"art rock"}, ReleaseYear: YearLower}},
+		{"no overlap", Guess{TrackID: "older"}, GuessResult{SharedGenres: []string{}, ReleaseYear: YearEqual}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := quizService.Guess(ctx, todaysQuiz.ID, tc.name, tc.given)
+			if err != nil {
+				t.Fatalf("Error checking guess: %v", err)
+			}
+			if result.Correct != tc.expected.Correct || result.SameArtist != tc.expected.SameArtist || result.SameAlbum != tc.expected.SameAlbum {
+				t.Errorf("Expected %+v, got %+v", tc.expected, result)
+			}
+			if result.ReleaseYear != tc.expected.ReleaseYear {
+				t.Errorf("Expected release year to be %s, got %s", tc.expected.ReleaseYear, result.ReleaseYear)
+			}
+			if !slices.Equal(result.SharedGenres, tc.expected.SharedGenres) {
+				t.Errorf("Expected shared genres to be %v, got %v", tc.expected.SharedGenres, result.SharedGenres)
+			}
+		})
+	}
+
+	if _, err := quizService.Guess(ctx, todaysQuiz.ID, "errors", Guess{}); !errors.Is(err, ErrEmptyGuess) {
+		t.Errorf("Expected ErrEmptyGuess, got %v", err)
+	}
+	if _, err := quizService.Guess(ctx, todaysQuiz.ID, "errors", Guess{TrackID: "missing"}); !errors.Is(err, ErrTrackNotFound) {
+		t.Errorf("Expected ErrTrackNotFound, got %v", err)
+	}
+	if _, err := quizService.Guess(ctx, todaysQuiz.ID, "correct", Guess{TrackID: "answer"}); !errors.Is(err, ErrQuizSolved) {
+		t.Errorf("Expected ErrQuizSolved, got %v", err)
+	}
+}
+
+func TestHintLadder(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+	repo := NewRepository(db)
+
+	var coverPNG bytes.Buffer
+	png.Encode(&coverPNG, image.NewRGBA(image.Rect(0, 0, 64, 64)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(coverPNG.Bytes())
+	}))
+	defer server.Close()
+
+	answer := fakeTrack("answer", "Wish You Were Here", "wywh", "1975-09-12", "floyd")
+	answer.Album.Images[0].URL = server.URL + "/wywh"
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{answer, fakeTrack("wrong", "Karma Police", "okc", "1997", "radiohead")},
+		[]spotify.Artist{fakeArtist("floyd", "art rock"), fakeArtist("radiohead", "art rock")},
+	)
+	todaysQuiz := buildQuiz(answer, []spotify.Artist{spotifyService.artists["floyd"]})
+	todaysQuiz.ID = DailyID(time.Now().UTC())
+	repo.SetQuizByDate(ctx, Edition{}, time.Now().UTC(), todaysQuiz)
+	quizService := NewService(repo, spotifyService)
+
+	clues, err := quizService.GetClues(ctx, todaysQuiz.ID, "player")
+	if err != nil {
+		t.Fatalf("Error getting clues: %v", err)
+	}
+	if clues.Genres != nil || clues.ReleaseYear != "" || clues.AudioSeconds > 0 {
+		t.Errorf("Expected no clues before the first guess, got %+v", clues)
+	}
+	if _, err := quizService.GetCover(ctx, todaysQuiz.ID, "player", 0); !errors.Is(err, ErrCoverLocked) {
+		t.Errorf("Expected ErrCoverLocked before the album image hint, got %v", err)
+	}
+
+	for level := HintGenres; level <= HintAudioPreview; level++ {
+		response, err := quizService.Guess(ctx, todaysQuiz.ID, "player", Guess{TrackID: "wrong"})
+		if err != nil {
+			t.Fatalf("Error checking guess: %v", err)
+		}
+		clues = response.Clues
+		if clues.Attempts != level {
+			t.Errorf("Expected %d attempts, got %d", level, clues.Attempts)
+		}
+		unlocked := map[int]bool{
+			HintGenres:       clues.Genres != nil,
+			HintReleaseYear:  clues.ReleaseYear != "",
+			HintAlbumImage:   clues.CoverLevel != 0,
+			HintArtists:      clues.Artists != nil,
+			HintAudioPreview: clues.AudioSeconds > 0,
+		}
+		for hint, isUnlocked := range unlocked {
+			if isUnlocked != (hint <= level) {
+				t.Errorf("Expected hint %d unlocked to be %v at level %d", hint, hint <= level, level)
+			}
+		}
+		// the album image is only served pixelated, sharpened by the next guesses
+		if clues.AlbumImage != "" {
+			t.Errorf("Expected the album image url to be hidden at level %d, got %s", level, clues.AlbumImage)
+		}
+		if level >= HintAlbumImage {
+			cover, err := quizService.GetCover(ctx, todaysQuiz.ID, "player", 0)
+			if err != nil || cover.Level != level+1 || cover.Level != clues.CoverLevel {
+				t.Errorf("Expected the cover pixelated on level %d, got %d, %v", level+1, cover.Level, err)
+			}
+		}
+	}
+
+	stored, err := quizService.GetClues(ctx, todaysQuiz.ID, "player")
+	if err != nil {
+		t.Fatalf("Error getting clues: %v", err)
+	}
+	if stored.Attempts != HintAudioPreview || stored.AudioSeconds == 0 {
+		t.Errorf("Expected session to be persisted, got %+v", stored)
+	}
+}
+
+func TestQuizArchive(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+	repo := NewRepository(db)
+
+	today := time.Now().UTC()
+	yesterday := today.AddDate(0, 0, -1)
+	lastWeek := today.AddDate(0, 0, -7)
+	for i, date := range []time.Time{today, yesterday, lastWeek} {
+		quiz := buildQuiz(fakeTrack(fmt.Sprint(i), "Track", "album", "2000"), nil)
+		quiz.ID = DailyID(date)
+		repo.SetQuizByDate(ctx, Edition{}, date, quiz)
+	}
+	quizService := NewService(repo, newFakeSpotifyService(nil, nil))
+
+	if _, err := quizService.GetQuiz(ctx, DailyID(yesterday)); err != nil {
+		t.Errorf("Error getting yesterday's quiz: %v", err)
+	}
+	if _, err := quizService.GetQuiz(ctx, DailyID(today.AddDate(0, 0, 1))); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("Expected ErrQuizNotFound for tomorrow's quiz, got %v", err)
+	}
+	if _, err := quizService.GetQuiz(ctx, "yesterday"); !errors.Is(err, ErrInvalidQuizID) {
+		t.Errorf("Expected ErrInvalidQuizID, got %v", err)
+	}
+
+	answer, err := quizService.GetAnswer(ctx, DailyID(yesterday))
+	if err != nil || answer.Track.ID != "1" {
+		t.Errorf("Expected yesterday's answer to be revealed, got %+v, %v", answer, err)
+	}
+	if _, err := quizService.GetAnswer(ctx, DailyID(today)); !errors.Is(err, ErrQuizNotRevealed) {
+		t.Errorf("Expected ErrQuizNotRevealed for today's quiz, got %v", err)
+	}
+
+	firstPage, err := quizService.ListQuizzes(ctx, Edition{}, 1, 5)
+	if err != nil {
+		t.Fatalf("Error listing quizzes: %v", err)
+	}
+	if len(firstPage) != 2 || firstPage[0].ID != DailyID(today) || firstPage[0].Revealed || !firstPage[1].Revealed {
+		t.Errorf("Expected today's and yesterday's quizzes on the first page, got %+v", firstPage)
+	}
+
+	secondPage, err := quizService.ListQuizzes(ctx, Edition{}, 2, 5)
+	if err != nil {
+		t.Fatalf("Error listing quizzes: %v", err)
+	}
+	if len(secondPage) != 1 || secondPage[0].ID != DailyID(lastWeek) {
+		t.Errorf("Expected last week's quiz on the second page, got %+v", secondPage)
+	}
+}
+
+func TestDailyRollover(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skipf("timezone database not available: %v", err)
+	}
+
+	// 23:30 in São Paulo is already the next day in UTC
+	now := time.Date(2024, time.October, 19, 2, 30, 0, 0, time.UTC)
+	quizService := NewService(nil, nil, WithLocation(saoPaulo), WithClock(func() time.Time { return now }))
+
+	if id := quizService.TodaysQuizID(Edition{}); id != "2024-10-18" {
+		t.Errorf("Expected today's quiz to be 2024-10-18, got %s", id)
+	}
+
+	expected := time.Date(2024, time.October, 19, 0, 0, 0, 0, saoPaulo)
+	if rollover := quizService.NextRollover(); !rollover.Equal(expected) {
+		t.Errorf("Expected next rollover at %v, got %v", expected, rollover)
+	}
+	if rollover := quizService.NextRollover(); rollover.Sub(now) != 30*time.Minute {
+		t.Errorf("Expected next rollover in 30 minutes, got %v", rollover.Sub(now))
+	}
+
+	testCases := []struct {
+		given    string
+		expected int
+	}{
+		{"2024-09-01", 1},
+		{"2024-09-02", 2},
+		{"2025-09-01", 366},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.given, func(t *testing.T) {
+			date, err := ParseDailyID(tc.given)
+			if err != nil {
+				t.Fatalf("Error parsing quiz id: %v", err)
+			}
+			if number := QuizNumber(date); number != tc.expected {
+				t.Errorf("Expected quiz number %d, got %d", tc.expected, number)
+			}
+		})
+	}
+}
+
+func TestGetTodaysQuizConcurrently(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd"),
+			fakeTrack("b", "Karma Police", "okc", "1997", "radiohead"),
+			fakeTrack("c", "Bohemian Rhapsody", "opera", "1975", "queen"),
+		},
+		[]spotify.Artist{fakeArtist("floyd"), fakeArtist("radiohead"), fakeArtist("queen")},
+	)
+	spotifyService.delay = 50 * time.Millisecond
+
+	// two services sharing the same database behave like two server instances
+	replicas := []*service{
+		NewService(NewRepository(db), spotifyService),
+		NewService(NewRepository(db), spotifyService),
+	}
+
+	var wg sync.WaitGroup
+	quizzes := make([]Quiz, 20)
+	for i := range quizzes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quiz, err := replicas[i%len(replicas)].GetTodaysQuiz(ctx, Edition{})
+			if err != nil {
+				t.Errorf("Error getting today's quiz: %v", err)
+			}
+			quizzes[i] = quiz
+		}()
+	}
+	wg.Wait()
+
+	if searches := spotifyService.randomSearches.Load(); searches != 1 {
+		t.Errorf("Expected the quiz to be generated once, got %d generations", searches)
+	}
+	for _, quiz := range quizzes {
+		if quiz.Track.ID != quizzes[0].Track.ID || quiz.Track.ID == "" {
+			t.Errorf("Expected every request to get the same quiz, got %s and %s", quiz.Track.ID, quizzes[0].Track.ID)
+		}
+	}
+}
+
+func TestGetTodaysQuizAfterLockExpiry(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd")},
+		[]spotify.Artist{fakeArtist("floyd")},
+	)
+	spotifyService.delay = 100 * time.Millisecond
+	repo := NewRepository(db)
+	quizService := NewService(repo, spotifyService)
+
+	done := make(chan Quiz)
+	go func() {
+		quiz, err := quizService.GetTodaysQuiz(ctx, Edition{})
+		if err != nil {
+			t.Errorf("Error getting today's quiz: %v", err)
+		}
+		done <- quiz
+	}()
+
+	// another instance stores its quiz while the generation outlives the lock
+	time.Sleep(20 * time.Millisecond)
+	today := time.Now().UTC()
+	stored := Quiz{ID: DailyID(today), Track: quizSong{ID: "b", Name: "Karma Police"}, CreatedAt: today}
+	if err := repo.SetQuizByDate(ctx, Edition{}, today, stored); err != nil {
+		t.Fatalf("Error storing the quiz of the other instance: %v", err)
+	}
+
+	if quiz := <-done; quiz.Track.ID != stored.Track.ID {
+		t.Errorf("Expected the quiz stored first to be returned, got %s", quiz.Track.ID)
+	}
+	quiz, err := repo.GetQuizByDate(ctx, Edition{}, today)
+	if err != nil || quiz.Track.ID != stored.Track.ID {
+		t.Errorf("Expected the quiz stored first to be kept, got %s, %v", quiz.Track.ID, err)
+	}
+}
+
+func TestFillBuffer(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	easy := fakeTrack("easy", "Another Brick in the Wall", "wall", "1979", "floyd")
+	easy.Popularity = 90
+	hard := fakeTrack("hard", "Cirrus Minor", "more", "1969", "floyd")
+	hard.Popularity = 40
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{easy, fakeTrack("normal", "Wish You Were Here", "wywh", "1975", "floyd"), hard},
+		[]spotify.Artist{fakeArtist("floyd")},
+	)
+	quizService := NewService(NewRepository(db), spotifyService)
+
+	// every day has a quiz for each difficulty
+	buffered, err := quizService.FillBuffer(ctx, 3)
+	if err != nil {
+		t.Fatalf("Error filling the quiz buffer: %v", err)
+	}
+	if buffered != 3 || spotifyService.randomSearches.Load() != 9 {
+		t.Errorf("Expected 9 quizzes to be generated, got %d buffered after %d generations", buffered, spotifyService.randomSearches.Load())
+	}
+
+	// quizzes already generated are kept
+	buffered, err = quizService.FillBuffer(ctx, 4)
+	if err != nil {
+		t.Fatalf("Error filling the quiz buffer: %v", err)
+	}
+	if buffered != 4 || spotifyService.randomSearches.Load() != 12 {
+		t.Errorf("Expected only 3 more quizzes to be generated, got %d buffered after %d generations", buffered, spotifyService.randomSearches.Load())
+	}
+
+	// quizzes generated ahead of time stay hidden until their day
+	tomorrow := DailyID(time.Now().UTC().AddDate(0, 0, 1))
+	if _, err := quizService.GetQuiz(ctx, tomorrow); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("Expected tomorrow's quiz to be hidden, got %v", err)
+	}
+	if _, err := quizService.GetTodaysQuiz(ctx, Edition{}); err != nil || spotifyService.randomSearches.Load() != 12 {
+		t.Errorf("Expected today's quiz to be read from the buffer, got %v", err)
+	}
+
+	// only the editions matching the filter are generated ahead of time, a month later
+	later := time.Now().AddDate(0, 1, 0)
+	spotifyService.randomSearches.Store(0)
+	quizService = NewService(NewRepository(db), spotifyService, WithClock(func() time.Time { return later }), WithBufferFilter(BufferFilter{
+		Categories:   []string{GeneralCategory},
+		Difficulties: []string{DifficultyNormal},
+	}))
+	buffered, err = quizService.FillBuffer(ctx, 2)
+	if err != nil || buffered != 2 || spotifyService.randomSearches.Load() != 2 {
+		t.Errorf("Expected only the normal quizzes to be generated, got %d buffered after %d generations, %v", buffered, spotifyService.randomSearches.Load(), err)
+	}
+	if quiz, err := quizService.GetTodaysQuiz(ctx, NewEdition("", DifficultyHard)); err != nil || quiz.Track.ID != "hard" || spotifyService.randomSearches.Load() != 3 {
+		t.Errorf("Expected the hard quiz to be generated on its first request, got %+v, %v", quiz, err)
+	}
+}
+
+func TestSeededGeneration(t *testing.T) {
+	ctx := context.Background()
+
+	tracks := make([]spotify.Track, 50)
+	for i := range tracks {
+		tracks[i] = fakeTrack(fmt.Sprint(i), fmt.Sprint("Track ", i), fmt.Sprint("album", i), "2000", "artist")
+	}
+	spotifyService := newFakeSpotifyService(tracks, []spotify.Artist{fakeArtist("artist")})
+
+	date := time.Date(2024, time.October, 18, 0, 0, 0, 0, time.UTC)
+	generateWith := func(date time.Time, opts ...Option) Quiz {
+		db, err := db.NewSQLiteDB(ctx, ":memory:")
+		if err != nil {
+			log.Fatalf("error connecting to in memory db: %v", err)
+		}
+		defer db.Close()
+
+		quiz, err := NewService(NewRepository(db), spotifyService, opts...).generateDailyQuiz(ctx, Edition{}, date)
+		if err != nil {
+			t.Fatalf("Error generating quiz: %v", err)
+		}
+		return quiz
+	}
+	generate := func(seed uint64, date time.Time) Quiz {
+		return generateWith(date, WithSeed(seed))
+	}
+
+	// services without a seed, like restarted or replicated instances, agree on the quiz of a day
+	if first, second := generateWith(date), generateWith(date); first.Track.ID != second.Track.ID {
+		t.Errorf("Expected the default seed to generate the same quiz, got %s and %s", first.Track.ID, second.Track.ID)
+	}
+
+	first := generate(42, date)
+	if second := generate(42, date); second.Track.ID != first.Track.ID {
+		t.Errorf("Expected the same seed and date to generate the same quiz, got %s and %s", first.Track.ID, second.Track.ID)
+	}
+
+	// a different seed or day should eventually pick another track
+	different := false
+	for i := 1; i <= 10 && !different; i++ {
+		different = generate(42, date.AddDate(0, 0, i)).Track.ID != first.Track.ID ||
+			generate(42+uint64(i), date).Track.ID != first.Track.ID
+	}
+	if !different {
+		t.Errorf("Expected different seeds and dates to generate different quizzes")
+	}
+}
+
+func TestTrackPickers(t *testing.T) {
+	unpopular := fakeTrack("unpopular", "B-Side", "b", "2000", "band")
+	unpopular.Popularity = 10
+	noPreview := fakeTrack("silent", "Silence", "s", "2000", "band")
+	noPreview.PreviewURL = ""
+	popular := fakeTrack("popular", "Hit", "h", "2000", "band")
+
+	spotifyService := newFakeSpotifyService([]spotify.Track{unpopular, noPreview, popular}, nil)
+	spotifyService.noRecommended = true
+
+	picker := func(name string, curated ...string) TrackPicker {
+		// each curated playlist gets its own ID, made of the track IDs
+		playlistID := fmt.Sprintf("%022s", strings.Join(curated, ""))
+		for _, id := range curated {
+			spotifyService.playlists[playlistID] = append(spotifyService.playlists[playlistID], spotifyService.tracks[id])
+		}
+		picker, err := NewTrackPicker(name, spotifyService, playlistID)
+		if err != nil {
+			t.Fatalf("Error creating the %s picker: %v", name, err)
+		}
+		return picker
+	}
+
+	testCases := []struct {
+		name     string
+		pickers  []TrackPicker
+		expected []string
+	}{
+		{"search filters by popularity", []TrackPicker{picker(PickerSearch)}, []string{"popular"}},
+		{"top tracks", []TrackPicker{picker(PickerTopTracks)}, []string{"popular"}},
+		{"curated", []TrackPicker{picker(PickerCurated, "unpopular", "silent")}, []string{"unpopular"}},
+		{"fallback", []TrackPicker{picker(PickerRecommendations), picker(PickerCurated, "silent"), picker(PickerSearch)}, []string{"popular"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for seed := uint64(0); seed < 10; seed++ {
+				track, err := pickTrack(tc.pickers, rand.New(rand.NewPCG(seed, seed)), Category{MinPopularity: 50})
+				if err != nil {
+					t.Fatalf("Error picking track: %v", err)
+				}
+				if !slices.Contains(tc.expected, track.ID) {
+					t.Errorf("Expected one of %v to be picked, got %s", tc.expected, track.ID)
+				}
+			}
+		})
+	}
+
+	_, err := pickTrack([]TrackPicker{picker(PickerRecommendations), picker(PickerCurated, "silent")}, rand.New(rand.NewPCG(1, 1)), Category{})
+	if !errors.Is(err, ErrNoTrackPicked) {
+		t.Errorf("Expected ErrNoTrackPicked when every picker fails, got %v", err)
+	}
+	if _, err := NewTrackPicker("unknown", spotifyService, ""); err == nil {
+		t.Errorf("Expected error creating an unknown picker, got nil")
+	}
+	if _, err := NewTrackPicker(PickerCurated, spotifyService, "hits"); !errors.Is(err, ErrInvalidPlaylist) {
+		t.Errorf("Expected ErrInvalidPlaylist creating the curated picker without a playlist, got %v", err)
+	}
+}
+
+func TestCategories(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			fakeTrack("70s", "Wish You Were Here", "wywh", "1975-09-12", "floyd"),
+			fakeTrack("90s", "Karma Police", "okc", "1997-05-21", "radiohead"),
+		},
+		[]spotify.Artist{fakeArtist("floyd"), fakeArtist("radiohead")},
+	)
+	nineties := Category{ID: "90s", Name: "90s", Genres: []string{"rock"}, YearFrom: 1990, YearTo: 1999, Market: "BR"}
+	quizService := NewService(NewRepository(db), spotifyService, WithCategories(nineties))
+
+	quiz, err := quizService.GetTodaysQuiz(ctx, Edition{Category: "90s"})
+	if err != nil {
+		t.Fatalf("Error getting today's 90s quiz: %v", err)
+	}
+	if quiz.Track.ID != "90s" || quiz.Category != "90s" {
+		t.Errorf("Expected the 90s quiz to have a track from the 90s, got %s", quiz.Track.ID)
+	}
+	if quiz.ID != quizService.TodaysQuizID(Edition{Category: "90s"}) {
+		t.Errorf("Expected the 90s quiz to have its own id, got %s", quiz.ID)
+	}
+
+	params := spotifyService.queries[0]
+	if !strings.Contains(params.Get("q"), `genre:"rock"`) || !strings.Contains(params.Get("q"), "year:1990-1999") || params.Get("market") != "BR" {
+		t.Errorf("Expected the search to be constrained by the category, got %v", params)
+	}
+
+	// the general quiz is stored under its own key
+	general, err := quizService.GetQuiz(ctx, quizService.TodaysQuizID(Edition{}))
+	if err != nil {
+		t.Fatalf("Error getting today's quiz: %v", err)
+	}
+	if general.ID == quiz.ID || general.Category != "" {
+		t.Errorf("Expected the general quiz to be a different quiz, got %s", general.ID)
+	}
+	if stored, err := quizService.GetQuiz(ctx, quiz.ID); err != nil || stored.Track.ID != quiz.Track.ID {
+		t.Errorf("Expected the 90s quiz to be stored, got %+v, %v", stored, err)
+	}
+
+	if _, err := quizService.GetTodaysQuiz(ctx, Edition{Category: "unknown"}); !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("Expected ErrCategoryNotFound, got %v", err)
+	}
+	if categories := quizService.Categories(); len(categories) != 1 || categories[0].ID != "90s" {
+		t.Errorf("Expected the 90s category to be listed, got %v", categories)
+	}
+
+	if _, err := LoadCategories("../../configs/categories.json"); err != nil {
+		t.Errorf("Error loading the categories configuration: %v", err)
+	}
+}
+
+func TestDifficulties(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	easy := fakeTrack("easy", "Another Brick in the Wall", "wall", "1979", "floyd")
+	easy.Popularity = 90
+	hard := fakeTrack("hard", "Cirrus Minor", "more", "1969", "floyd")
+	hard.Popularity = 40
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{easy, fakeTrack("normal", "Wish You Were Here", "wywh", "1975", "floyd"), hard},
+		[]spotify.Artist{fakeArtist("floyd")},
+	)
+	quizService := NewService(NewRepository(db), spotifyService)
+
+	// the normal quiz is picked from every track with a popularity of 80 or more, like the default quiz always was
+	expected := map[string][]string{
+		DifficultyEasy:   {"easy"},
+		DifficultyNormal: {"easy", "normal"},
+		DifficultyHard:   {"hard"},
+	}
+	for _, difficulty := range Difficulties {
+		edition := NewEdition("", difficulty)
+		quiz, err := quizService.GetTodaysQuiz(ctx, edition)
+		if err != nil {
+			t.Fatalf("Error getting today's %s quiz: %v", difficulty, err)
+		}
+		if !slices.Contains(expected[difficulty], quiz.Track.ID) || quiz.Difficulty != difficulty {
+			t.Errorf("Expected the %s quiz to have one of the %v tracks, got %s", difficulty, expected[difficulty], quiz.Track.ID)
+		}
+		if level := difficultyLevels[difficulty]; level.distance(quiz.DifficultyScore) != 0 {
+			t.Errorf("Expected the %s quiz to score within %d-%d, got %d", difficulty, level.minScore, level.maxScore, quiz.DifficultyScore)
+		}
+
+		clues, err := quizService.GetClues(ctx, quiz.ID, "session")
+		if err != nil || clues.DifficultyScore != quiz.DifficultyScore || clues.NextRollover == nil {
+			t.Errorf("Expected the clues to have the difficulty score of the %s quiz, got %+v, %v", difficulty, clues, err)
+		}
+	}
+
+	// the normal quiz keeps the plain date id
+	date := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		edition Edition
+		id      string
+	}{
+		{NewEdition("", DifficultyNormal), "2024-09-01"},
+		{NewEdition("", DifficultyHard), "2024-09-01::hard"},
+		{NewEdition("90s", ""), "2024-09-01:90s"},
+		{NewEdition("90s", DifficultyEasy), "2024-09-01:90s:easy"},
+	}
+	for _, tc := range testCases {
+		if id := tc.edition.QuizID(date); id != tc.id {
+			t.Errorf("Expected id %s, got %s", tc.id, id)
+		}
+		if _, edition, err := ParseQuizID(tc.id); err != nil || edition != tc.edition {
+			t.Errorf("Expected %s to be parsed as %+v, got %+v, %v", tc.id, tc.edition, edition, err)
+		}
+	}
+
+	if _, err := quizService.GetTodaysQuiz(ctx, NewEdition("", "impossible")); !errors.Is(err, ErrDifficultyNotFound) {
+		t.Errorf("Expected ErrDifficultyNotFound, got %v", err)
+	}
+
+	// obscure artists make a track harder to guess
+	obscure := fakeArtist("obscure")
+	obscure.Followers.Total = 100
+	if DifficultyScore(easy, []spotify.Artist{obscure}) <= DifficultyScore(easy, []spotify.Artist{fakeArtist("floyd")}) {
+		t.Errorf("Expected fewer followers to make the quiz harder")
+	}
+}
+
+func TestPracticeQuiz(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd")},
+		[]spotify.Artist{fakeArtist("floyd")},
+	)
+	quizService := NewService(NewRepository(db), spotifyService, WithPracticeTTL(50*time.Millisecond))
+
+	clues, err := quizService.NewPracticeQuiz(ctx, Edition{}, "player")
+	if err != nil {
+		t.Fatalf("Error generating practice quiz: %v", err)
+	}
+	if !strings.HasPrefix(clues.ID, practicePrefix) || clues.ExpiresAt == nil || clues.NextRollover != nil {
+		t.Errorf("Expected an expiring practice quiz, got %+v", clues)
+	}
+	if another, err := quizService.NewPracticeQuiz(ctx, Edition{}, "player"); err != nil || another.ID == clues.ID {
+		t.Errorf("Expected every practice quiz to have its own id, got %s, %v", another.ID, err)
+	}
+
+	result, err := quizService.Guess(ctx, clues.ID, "player", Guess{TrackID: "a"})
+	if err != nil || !result.Correct {
+		t.Errorf("Expected the guess to be checked against the practice quiz, got %+v, %v", result, err)
+	}
+	if _, err := quizService.GetClues(ctx, clues.ID, "someone else"); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("Expected the practice quiz to be hidden from other sessions, got %v", err)
+	}
+
+	// the daily quiz is left untouched
+	if spotifyService.randomSearches.Load() != 2 {
+		t.Errorf("Expected only the practice quizzes to be generated, got %d generations", spotifyService.randomSearches.Load())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := quizService.GetClues(ctx, clues.ID, "player"); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("Expected the practice quiz to expire, got %v", err)
+	}
+}
+
+func TestAutocomplete(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			fakeTrack("wywh", "Wish You Were Here", "wywh", "1975", "floyd"),
+			fakeTrack("wonderwall", "Wonderwall", "morning", "1995", "oasis"),
+		},
+		nil,
+	)
+	quizService := NewService(NewRepository(db), spotifyService)
+
+	suggestions, err := quizService.Autocomplete(ctx, "  Wish  You ")
+	if err != nil {
+		t.Fatalf("Error getting suggestions: %v", err)
+	}
+	expected := []Suggestion{{ID: "wywh", Name: "Wish You Were Here", Artist: "Artist floyd", Image: "https://i.scdn.co/image/wywh"}}
+	if !slices.Equal(suggestions, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, suggestions)
+	}
+
+	// the same query, however it's typed, is served from the cache
+	if _, err := quizService.Autocomplete(ctx, "wish you"); err != nil {
+		t.Fatalf("Error getting suggestions: %v", err)
+	}
+	if len(spotifyService.queries) != 1 || spotifyService.queries[0].Get("q") != "wish you" || spotifyService.queries[0].Get("limit") != "10" {
+		t.Errorf("Expected a single search for the query, got %v", spotifyService.queries)
+	}
+
+	if _, err := quizService.Autocomplete(ctx, " w "); !errors.Is(err, ErrQueryTooShort) {
+		t.Errorf("Expected ErrQueryTooShort, got %v", err)
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	testCases := []struct {
+		given    string
+		expected string
+	}{
+		{"Don't Stop Me Now - Remastered 2011", "dont stop me now"},
+		{"dont stop me now", "dont stop me now"},
+		{"Águas de Março", "aguas de marco"},
+		{"Old Town Road (feat. Billy Ray Cyrus) - Remix", "old town road"},
+		{"Stay With Me ft. Mary J. Blige", "stay with me"},
+		{"Bohemian Rhapsody - Live Aid", "bohemian rhapsody"},
+		{"Mr. Brightside [Radio Edit]", "mr brightside"},
+		{"Love & War", "love and war"},
+		{"Twenty-One Pilots", "twenty one pilots"},
+	}
+	for _, tc := range testCases {
+		if normalized := normalizeTitle(tc.given); normalized != tc.expected {
+			t.Errorf("Expected %q to be normalized as %q, got %q", tc.given, tc.expected, normalized)
+		}
+	}
+}
+
+func TestFuzzyGuess(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+	repo := NewRepository(db)
+
+	answer := fakeTrack("answer", "Don't Stop Me Now - Remastered 2011", "jazz", "1978", "queen")
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			answer,
+			fakeTrack("live", "Don't Stop Me Now - Live at Wembley", "wembley", "1986", "queen"),
+			fakeTrack("cover", "Don't Stop Me Now", "glee", "2010", "glee"),
+			fakeTrack("other", "Don't Stop Believin'", "escape", "1981", "journey"),
+		},
+		[]spotify.Artist{fakeArtist("queen", "rock"), fakeArtist("glee", "pop"), fakeArtist("journey", "rock")},
+	)
+	todaysQuiz := buildQuiz(answer, []spotify.Artist{spotifyService.artists["queen"]})
+	todaysQuiz.ID = DailyID(time.Now().UTC())
+	repo.SetQuizByDate(ctx, Edition{}, time.Now().UTC(), todaysQuiz)
+	quizService := NewService(repo, spotifyService)
+
+	testCases := []struct {
+		name    string
+		given   Guess
+		correct bool
+		close   bool
+	}{
+		{"typed title", Guess{TrackName: "dont stop me now"}, true, false},
+		{"another release", Guess{TrackID: "live"}, true, false},
+		{"cover", Guess{TrackID: "cover"}, false, true},
+		{"typo", Guess{TrackName: "dont stop me nw"}, true, false},
+		{"close title", Guess{TrackName: "dont stop me"}, false, true},
+		{"different song", Guess{TrackID: "other"}, false, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := quizService.Guess(ctx, todaysQuiz.ID, tc.name, tc.given)
+			if err != nil {
+				t.Fatalf("Error checking guess: %v", err)
+			}
+			if result.Correct != tc.correct || result.Close != tc.close {
+				t.Errorf("Expected correct %v and close %v, got %+v", tc.correct, tc.close, result)
+			}
+		})
+	}
+
+	// stricter thresholds turn the typo into a close guess
+	strict := NewService(repo, spotifyService, WithMatchThresholds(1, 0.8))
+	result, err := strict.Guess(ctx, todaysQuiz.ID, "strict", Guess{TrackName: "dont stop me nw"})
+	if err != nil || result.Correct || !result.Close {
+		t.Errorf("Expected the typo to be close with strict thresholds, got %+v, %v", result, err)
+	}
+}
+
+func TestShare(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+	repo := NewRepository(db)
+
+	answer := fakeTrack("answer", "Wish You Were Here", "wywh", "1975-09-12", "floyd")
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			answer,
+			fakeTrack("sibling", "Have a Cigar", "wywh", "1975-09-12", "floyd"),
+			fakeTrack("newer", "Karma Police", "okc", "1997", "radiohead"),
+		},
+		[]spotify.Artist{fakeArtist("floyd", "art rock"), fakeArtist("radiohead", "art rock")},
+	)
+	today := time.Now().UTC()
+	todaysQuiz := buildQuiz(answer, []spotify.Artist{spotifyService.artists["floyd"]})
+	todaysQuiz.ID = DailyID(today)
+	todaysQuiz.Number = 412
+	todaysQuiz.Difficulty = DifficultyNormal
+	repo.SetQuizByDate(ctx, Edition{}, today, todaysQuiz)
+	quizService := NewService(repo, spotifyService)
+
+	for _, trackID := range []string{"newer", "sibling"} {
+		if _, err := quizService.Guess(ctx, todaysQuiz.ID, "player", Guess{TrackID: trackID}); err != nil {
+			t.Fatalf("Error checking guess: %v", err)
+		}
+	}
+	if _, err := quizService.GetShare(ctx, todaysQuiz.ID, "player"); !errors.Is(err, ErrQuizNotFinished) {
+		t.Errorf("Expected ErrQuizNotFinished before solving the quiz, got %v", err)
+	}
+	if _, err := quizService.Guess(ctx, todaysQuiz.ID, "player", Guess{TrackID: "answer"}); err != nil {
+		t.Fatalf("Error checking guess: %v", err)
+	}
+
+	share, err := quizService.GetShare(ctx, todaysQuiz.ID, "player")
+	if err != nil {
+		t.Fatalf("Error sharing results: %v", err)
+	}
+	expected := "Spotifydle #412 🎵 3 guesses\n\n⬛⬛⬛🟨⬇️\n⬛🟩🟩🟨🟩\n🟩🟩🟩🟩🟩"
+	if share.Text != expected {
+		t.Errorf("Expected share text %q, got %q", expected, share.Text)
+	}
+	if strings.Contains(share.Text, answer.Name) {
+		t.Errorf("Expected the share text to be spoiler free, got %q", share.Text)
+	}
+
+	img := share.Image()
+	if img.Bounds().Dx() != cardWidth || img.Bounds().Dy() != cardHeight {
+		t.Errorf("Expected a %dx%d card, got %v", cardWidth, cardHeight, img.Bounds())
+	}
+	if img.At(cardWidth-cardPadding-1, cardHeight/2) == color.Color(cardBackground) {
+		t.Errorf("Expected the grid to be drawn on the card")
+	}
+
+	titled := NewService(repo, spotifyService, WithShareTitle("Songle"))
+	if share, err := titled.GetShare(ctx, todaysQuiz.ID, "player"); err != nil || !strings.HasPrefix(share.Text, "Songle #412 🎵") {
+		t.Errorf("Expected the results to be headed by the configured title, got %q, %v", share.Text, err)
+	}
+}
+
+func TestPlayerStats(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	// the wrong track has no preview, so it's never picked as the answer
+	wrong := fakeTrack("wrong", "Karma Police", "okc", "1997", "radiohead")
+	wrong.PreviewURL = ""
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{fakeTrack("answer", "Wish You Were Here", "wywh", "1975", "floyd"), wrong},
+		[]spotify.Artist{fakeArtist("floyd"), fakeArtist("radiohead")},
+	)
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	quizService := NewService(NewRepository(db), spotifyService, WithClock(func() time.Time { return now }))
+
+	play := func(guesses ...string) {
+		for _, trackID := range guesses {
+			if _, err := quizService.Guess(ctx, quizService.TodaysQuizID(Edition{}), "player", Guess{TrackID: trackID}); err != nil {
+				t.Fatalf("Error checking guess: %v", err)
+			}
+		}
+	}
+
+	play("wrong", "answer")
+	now = now.AddDate(0, 0, 1)
+	play("answer")
+
+	stats, err := quizService.GetStats(ctx, "player", Edition{})
+	if err != nil {
+		t.Fatalf("Error getting stats: %v", err)
+	}
+	if stats.GamesPlayed != 2 || stats.GamesWon != 2 || stats.WinRate != 1 || stats.CurrentStreak != 2 || stats.MaxStreak != 2 {
+		t.Errorf("Expected 2 wins in a row, got %+v", stats)
+	}
+	if stats.Distribution[1] != 1 || stats.Distribution[2] != 1 {
+		t.Errorf("Expected a win in 1 guess and another in 2, got %v", stats.Distribution)
+	}
+
+	// skipping a day breaks the streak, and unsolved games count as played
+	now = now.AddDate(0, 0, 2)
+	play("wrong")
+	stats, err = quizService.GetStats(ctx, "player", Edition{})
+	if err != nil {
+		t.Fatalf("Error getting stats: %v", err)
+	}
+	if stats.GamesPlayed != 3 || stats.GamesWon != 2 || stats.CurrentStreak != 0 || stats.MaxStreak != 2 {
+		t.Errorf("Expected the streak to be broken, got %+v", stats)
+	}
+
+	// replaying a past quiz doesn't count
+	if _, err := quizService.Guess(ctx, DailyID(now.AddDate(0, 0, -3)), "latecomer", Guess{TrackID: "answer"}); err != nil {
+		t.Fatalf("Error checking guess: %v", err)
+	}
+	if replayed, _ := quizService.GetStats(ctx, "latecomer", Edition{}); replayed.GamesPlayed != 0 {
+		t.Errorf("Expected replays to be left out of the stats, got %+v", replayed)
+	}
+
+	if _, err := quizService.GetStats(ctx, "player", Edition{Difficulty: "impossible"}); !errors.Is(err, ErrDifficultyNotFound) {
+		t.Errorf("Expected ErrDifficultyNotFound, got %v", err)
+	}
+}
+
+func TestQuizStats(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	wrong := fakeTrack("wrong", "Karma Police", "okc", "1997", "radiohead")
+	wrong.PreviewURL = ""
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{fakeTrack("answer", "Wish You Were Here", "wywh", "1975", "floyd"), wrong},
+		[]spotify.Artist{fakeArtist("floyd"), fakeArtist("radiohead")},
+	)
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	quizService := NewService(NewRepository(db), spotifyService, WithClock(func() time.Time { return now }))
+	quizID := quizService.TodaysQuizID(Edition{})
+
+	guess := func(player, trackID string) {
+		if _, err := quizService.Guess(ctx, quizID, player, Guess{TrackID: trackID}); err != nil {
+			t.Fatalf("Error checking guess: %v", err)
+		}
+	}
+
+	serve := func(player string) {
+		if _, err := quizService.GetClues(ctx, quizID, player); err != nil {
+			t.Fatalf("Error getting clues: %v", err)
+		}
+	}
+
+	// solves are timed from when the quiz is first served, not from the first guess
+	serve("slow")
+	serve("quick")
+	serve("fast")
+	serve("stuck")
+	guess("slow", "wrong")
+	guess("quick", "wrong")
+	now = now.Add(30 * time.Second)
+	serve("fast")
+	guess("quick", "answer")
+	guess("fast", "answer")
+	// a player guessing without being served the quiz can't be timed
+	guess("unserved", "answer")
+	now = now.Add(time.Minute)
+	guess("slow", "answer")
+	guess("stuck", "wrong")
+
+	stats, err := quizService.GetQuizStats(ctx, quizID, "quick")
+	if err != nil {
+		t.Fatalf("Error getting quiz stats: %v", err)
+	}
+	if stats.Players != 5 || stats.Solved != 4 || stats.SolveRate != 0.8 || stats.AverageAttempts != 6.0/4 {
+		t.Errorf("Expected 4 of 5 players to solve the quiz in 6 attempts, got %+v", stats)
+	}
+
+	expected := []LeaderboardEntry{
+		{Rank: 1, Player: playerName("fast"), Attempts: 1, TimeMs: 30_000},
+		{Rank: 2, Player: playerName("quick"), Attempts: 2, TimeMs: 30_000, Me: true},
+		{Rank: 3, Player: playerName("slow"), Attempts: 2, TimeMs: 90_000},
+	}
+	if !slices.Equal(stats.Fastest, expected) {
+		t.Errorf("Expected leaderboard %+v, got %+v", expected, stats.Fastest)
+	}
+	if top, err := quizService.GetLeaderboard(ctx, quizID, "", 1); err != nil || len(top) != 1 || top[0].Player != playerName("fast") {
+		t.Errorf("Expected the leaderboard to be limited to the best solve, got %+v, %v", top, err)
+	}
+
+	// replays of past quizzes are left out
+	now = now.AddDate(0, 0, 1)
+	guess("late", "answer")
+	if stats, err := quizService.GetQuizStats(ctx, quizID, ""); err != nil || stats.Players != 5 {
+		t.Errorf("Expected replays to be left out of the quiz stats, got %+v, %v", stats, err)
+	}
+}
+
+func TestCoverMode(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	// a cover split in a red and a blue half
+	cover := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(cover, image.Rect(0, 0, 32, 64), image.NewUniform(color.RGBA{0xff, 0, 0, 0xff}), image.Point{}, draw.Src)
+	draw.Draw(cover, image.Rect(32, 0, 64, 64), image.NewUniform(color.RGBA{0, 0, 0xff, 0xff}), image.Point{}, draw.Src)
+	var coverPNG bytes.Buffer
+	png.Encode(&coverPNG, cover)
+
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		w.Write(coverPNG.Bytes())
+	}))
+	defer server.Close()
+
+	answer := fakeTrack("answer", "Wish You Were Here", "wywh", "1975", "floyd")
+	answer.Album.Images[0].URL = server.URL + "/wywh"
+	sibling := fakeTrack("sibling", "Have a Cigar", "wywh", "1975", "floyd")
+	other := fakeTrack("other", "Karma Police", "okc", "1997", "radiohead")
+	sibling.PreviewURL, other.PreviewURL = "", "" // never picked as the answer
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{answer, sibling, other},
+		[]spotify.Artist{fakeArtist("floyd"), fakeArtist("radiohead")},
+	)
+	edition := Edition{}.WithMode(ModeCover)
+
+	if _, err := NewService(NewRepository(db), spotifyService).GetTodaysQuiz(ctx, edition); !errors.Is(err, ErrModeNotFound) {
+		t.Errorf("Expected ErrModeNotFound when the cover mode isn't enabled, got %v", err)
+	}
+
+	quizService := NewService(NewRepository(db), spotifyService, WithModes(ModeCover))
+	quiz, err := quizService.GetTodaysQuiz(ctx, edition)
+	if err != nil {
+		t.Fatalf("Error generating cover quiz: %v", err)
+	}
+	if quiz.ID != "cover-"+DailyID(time.Now().UTC()) || quiz.Mode != ModeCover {
+		t.Errorf("Expected a cover quiz with a prefixed id, got %s in mode %s", quiz.ID, quiz.Mode)
+	}
+	if _, parsed, err := ParseQuizID(quiz.ID); err != nil || parsed != edition {
+		t.Errorf("Expected the cover quiz id to parse back to its edition, got %+v, %v", parsed, err)
+	}
+
+	clues, err := quizService.GetClues(ctx, quiz.ID, "player")
+	if err != nil {
+		t.Fatalf("Error getting clues: %v", err)
+	}
+	if clues.AlbumImage != "" || clues.CoverLevel != 1 {
+		t.Errorf("Expected the cover url to be hidden on level 1, got %+v", clues)
+	}
+	if _, err := quizService.GetCover(ctx, quiz.ID, "player", 2); !errors.Is(err, ErrCoverLocked) {
+		t.Errorf("Expected ErrCoverLocked for a level not unlocked yet, got %v", err)
+	}
+
+	pixelated, err := quizService.GetCover(ctx, quiz.ID, "player", 0)
+	if err != nil {
+		t.Fatalf("Error getting cover: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(pixelated.Data))
+	if err != nil {
+		t.Fatalf("Error decoding cover: %v", err)
+	}
+	// 6 blocks of 11 pixels across, the third one blending both halves
+	if pixelated.Level != 1 || img.Bounds() != cover.Bounds() || img.At(0, 0) != img.At(10, 10) || img.At(30, 0) == cover.At(30, 0) {
+		t.Errorf("Expected the cover to be pixelated on level 1, got level %d", pixelated.Level)
+	}
+
+	result, err := quizService.Guess(ctx, quiz.ID, "player", Guess{TrackID: "other"})
+	if err != nil || result.Correct {
+		t.Fatalf("Expected a wrong guess, got %+v, %v", result, err)
+	}
+	if sharper, err := quizService.GetCover(ctx, quiz.ID, "player", 0); err != nil || sharper.Level != 2 {
+		t.Errorf("Expected a wrong guess to unlock level 2, got %d, %v", sharper.Level, err)
+	}
+
+	// any track of the album names the cover
+	result, err = quizService.Guess(ctx, quiz.ID, "player", Guess{TrackID: "sibling"})
+	if err != nil || !result.Correct || result.Clues.AlbumImage != answer.Album.Images[0].URL {
+		t.Errorf("Expected a track of the album to solve the quiz and reveal the cover, got %+v, %v", result, err)
+	}
+	original, err := quizService.GetCover(ctx, quiz.ID, "player", 0)
+	if err != nil || original.Level != 0 || !bytes.Equal(original.Data, coverPNG.Bytes()) {
+		t.Errorf("Expected the original cover once solved, got level %d, %v", original.Level, err)
+	}
+	if downloads.Load() != 1 {
+		t.Errorf("Expected the cover to be downloaded once, got %d downloads", downloads.Load())
+	}
+
+	// giving up reveals the answer and finishes the session
+	gaveUp, err := quizService.GiveUp(ctx, quiz.ID, "quitter")
+	if err != nil || gaveUp.Album.Image != answer.Album.Images[0].URL {
+		t.Fatalf("Expected giving up to reveal the answer, got %+v, %v", gaveUp, err)
+	}
+	if _, err := quizService.GetCover(ctx, quiz.ID, "quitter", 0); err != nil {
+		t.Errorf("Expected the original cover once given up, got %v", err)
+	}
+	if _, err := quizService.Guess(ctx, quiz.ID, "quitter", Guess{TrackID: "answer"}); !errors.Is(err, ErrQuizSolved) {
+		t.Errorf("Expected ErrQuizSolved after giving up, got %v", err)
+	}
+	share, err := quizService.GetShare(ctx, quiz.ID, "quitter")
+	if err != nil || !strings.HasPrefix(share.Text, "Spotifydle #") || !strings.Contains(share.Text, "(cover) 🎵 gave up after 0 guesses") {
+		t.Errorf("Expected the share text to tell the quiz was given up, got %q, %v", share.Text, err)
+	}
+	stats, err := quizService.GetStats(ctx, "quitter", edition)
+	if err != nil || stats.GamesPlayed != 1 || stats.GamesWon != 0 {
+		t.Errorf("Expected giving up to count as a loss, got %+v, %v", stats, err)
+	}
+}
+
+func TestAudioClips(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+	repo := NewRepository(db)
+
+	// 30 seconds of silent MPEG-1 layer III frames at 128 kbps and 44.1 kHz, tagged with the title
+	const frameLength, frameDuration = 417, 1152.0 / 44100
+	var preview bytes.Buffer
+	tag := "TIT2 Wish You Were Here"
+	preview.Write([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, byte(len(tag))})
+	preview.WriteString(tag)
+	for i := 0; i < 1149; i++ {
+		frame := make([]byte, frameLength)
+		copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+		preview.Write(frame)
+	}
+
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		w.Write(preview.Bytes())
+	}))
+	defer server.Close()
+
+	answer := fakeTrack("answer", "Wish You Were Here", "wywh", "1975-09-12", "floyd")
+	answer.PreviewURL = server.URL + "/answer"
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{answer, fakeTrack("wrong", "Karma Police", "okc", "1997", "radiohead")},
+		[]spotify.Artist{fakeArtist("floyd"), fakeArtist("radiohead")},
+	)
+	todaysQuiz := buildQuiz(answer, []spotify.Artist{spotifyService.artists["floyd"]})
+	todaysQuiz.ID = DailyID(time.Now().UTC())
+	repo.SetQuizByDate(ctx, Edition{}, time.Now().UTC(), todaysQuiz)
+	quizService := NewService(repo, spotifyService)
+
+	if _, err := quizService.GetAudio(ctx, todaysQuiz.ID, "player", 0); !errors.Is(err, ErrAudioLocked) {
+		t.Errorf("Expected ErrAudioLocked before the audio preview hint, got %v", err)
+	}
+
+	var clues Clues
+	for level := HintGenres; level <= HintAudioPreview; level++ {
+		response, err := quizService.Guess(ctx, todaysQuiz.ID, "player", Guess{TrackID: "wrong"})
+		if err != nil {
+			t.Fatalf("Error checking guess: %v", err)
+		}
+		clues = response.Clues
+	}
+	if clues.AudioSeconds != hintAudioSeconds || clues.AudioPreview != "" {
+		t.Errorf("Expected a %d seconds clip without the preview url, got %+v", hintAudioSeconds, clues)
+	}
+
+	clip, err := quizService.GetAudio(ctx, todaysQuiz.ID, "player", 0)
+	if err != nil {
+		t.Fatalf("Error getting audio: %v", err)
+	}
+	frames := len(clip.Data) / frameLength
+	if len(clip.Data)%frameLength != 0 || float64(frames)*frameDuration < hintAudioSeconds || float64(frames-1)*frameDuration >= hintAudioSeconds {
+		t.Errorf("Expected the clip to be trimmed to whole frames covering %d seconds, got %d bytes", hintAudioSeconds, len(clip.Data))
+	}
+	if bytes.Contains(clip.Data, []byte("Wish You Were Here")) {
+		t.Errorf("Expected the clip to be stripped of its tags")
+	}
+	if _, err := quizService.GetAudio(ctx, todaysQuiz.ID, "player", previewSeconds); !errors.Is(err, ErrAudioLocked) {
+		t.Errorf("Expected ErrAudioLocked for a longer clip than unlocked, got %v", err)
+	}
+
+	response, err := quizService.Guess(ctx, todaysQuiz.ID, "player", Guess{TrackID: "answer"})
+	if err != nil || response.Clues.AudioSeconds != previewSeconds || response.Clues.AudioPreview != answer.PreviewURL {
+		t.Errorf("Expected the whole preview once solved, got %+v, %v", response.Clues, err)
+	}
+	full, err := quizService.GetAudio(ctx, todaysQuiz.ID, "player", 0)
+	if err != nil || len(full.Data) != 1149*frameLength {
+		t.Errorf("Expected the whole preview once solved, got %d bytes, %v", len(full.Data), err)
+	}
+	if downloads.Load() != 1 {
+		t.Errorf("Expected the preview to be downloaded once, got %d downloads", downloads.Load())
+	}
+}
+
+func TestArtistMode(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	floyd, radiohead, oasis := fakeArtist("floyd", "art rock", "progressive rock"), fakeArtist("radiohead", "art rock"), fakeArtist("oasis", "britpop")
+	floyd.Followers.Total, radiohead.Followers.Total, oasis.Followers.Total = 12_000_000, 5_000_000, 12_000_000
+	other := fakeTrack("other", "Karma Police", "okc", "1997", "radiohead")
+	other.PreviewURL = "" // never picked as the answer
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{fakeTrack("answer", "Wish You Were Here", "wywh", "1975", "floyd"), other},
+		[]spotify.Artist{floyd, radiohead, oasis},
+	)
+	quizService := NewService(NewRepository(db), spotifyService, WithModes(ModeArtist))
+	edition := Edition{}.WithMode(ModeArtist)
+
+	quiz, err := quizService.GetTodaysQuiz(ctx, edition)
+	if err != nil {
+		t.Fatalf("Error generating artist quiz: %v", err)
+	}
+	if len(quiz.Artists) != 1 || quiz.Artists[0].ID != "floyd" || quiz.Track.AudioPreview == "" {
+		t.Fatalf("Expected the artist of the picked track with a top track snippet, got %+v", quiz)
+	}
+	if !slices.Equal(quiz.RelatedArtists, []string{"Artist radiohead", "Artist oasis"}) {
+		t.Errorf("Expected the closest related artists to be revealed last, got %v", quiz.RelatedArtists)
+	}
+
+	clues, err := quizService.GetClues(ctx, quiz.ID, "player")
+	if err != nil {
+		t.Fatalf("Error getting clues: %v", err)
+	}
+	if clues.Followers != "10M-100M" || clues.Genres != nil || clues.ReleaseYear != "" || clues.AlbumImage != "" || clues.Artists != nil {
+		t.Errorf("Expected only the follower range before the first guess, got %+v", clues)
+	}
+	if _, err := quizService.Guess(ctx, quiz.ID, "player", Guess{TrackID: "answer"}); !errors.Is(err, ErrEmptyGuess) {
+		t.Errorf("Expected track guesses to be rejected in artist mode, got %v", err)
+	}
+
+	result, err := quizService.Guess(ctx, quiz.ID, "player", Guess{ArtistID: "radiohead"})
+	if err != nil {
+		t.Fatalf("Error checking guess: %v", err)
+	}
+	if result.Correct || !slices.Equal(result.SharedGenres, []string{"art rock"}) || result.Followers != FollowersHigher || result.FollowerDistance != 0.4 {
+		t.Errorf("Expected genres and followers to be compared, got %+v", result.GuessResult)
+	}
+	if result.Clues.Genres == nil || result.Clues.RelatedArtists != nil {
+		t.Errorf("Expected the genres to be unlocked by the first wrong guess, got %+v", result.Clues)
+	}
+
+	result, err = quizService.Guess(ctx, quiz.ID, "player", Guess{ArtistName: "oasis"})
+	if err != nil || result.Correct || result.ArtistID != "oasis" || result.Followers != FollowersEqual {
+		t.Fatalf("Expected a typed name to be looked up, got %+v, %v", result.GuessResult, err)
+	}
+	if !slices.Equal(result.Clues.RelatedArtists, []string{"Artist radiohead"}) {
+		t.Errorf("Expected the first related artist to be unlocked, got %v", result.Clues.RelatedArtists)
+	}
+
+	result, err = quizService.Guess(ctx, quiz.ID, "player", Guess{ArtistName: "artist floyd"})
+	if err != nil || !result.Correct || !slices.Equal(result.Clues.Artists, []string{"Artist floyd"}) {
+		t.Errorf("Expected a typed name to solve the quiz and reveal the artist, got %+v, %v", result, err)
+	}
+
+	share, err := quizService.GetShare(ctx, quiz.ID, "player")
+	if err != nil || !slices.Equal(share.Grid, []string{"⬛🟨⬆️", "⬛⬛🟩", "🟩🟩🟩"}) {
+		t.Errorf("Expected an artist grid, got %v, %v", share.Grid, err)
+	}
+}
+
+func TestQuizValidation(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	noImage := fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd")
+	noImage.Album.Images = nil
+	explicit := fakeTrack("b", "Money", "dsotm", "1973", "floyd", "floyd")
+	explicit.Explicit = true
+	artists := []spotify.Artist{fakeArtist("floyd")}
+	noGenres := []spotify.Artist{{ID: "floyd", Name: "Artist floyd"}}
+
+	testCases := []struct {
+		name     string
+		track    spotify.Track
+		artists  []spotify.Artist
+		opts     []Option
+		expected error
+	}{
+		{"album without image", noImage, artists, nil, ErrMissingImage},
+		{"artist without genres", fakeTrack("c", "Time", "dsotm", "1973", "floyd"), noGenres, nil, ErrMissingGenres},
+		{"explicit track filtered", explicit, artists, []Option{WithExplicitFilter(true)}, ErrExplicitContent},
+		{"explicit track allowed", explicit, artists, nil, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spotifyService := newFakeSpotifyService([]spotify.Track{tc.track}, tc.artists)
+			quizService := NewService(NewRepository(db), spotifyService, tc.opts...)
+
+			clues, err := quizService.NewPracticeQuiz(ctx, Edition{}, "player")
+			if tc.expected == nil {
+				if err != nil {
+					t.Fatalf("Error generating practice quiz: %v", err)
+				}
+				if len(clues.Artists) != 0 {
+					t.Errorf("Expected the artists to be hidden, got %v", clues.Artists)
+				}
+				return
+			}
+
+			var noValid *ErrNoValidQuiz
+			if !errors.As(err, &noValid) || !errors.Is(err, tc.expected) {
+				t.Fatalf("Expected ErrNoValidQuiz wrapping %v, got %v", tc.expected, err)
+			}
+			if noValid.Attempts != maxValidationAttempts || len(noValid.Reasons) != maxValidationAttempts {
+				t.Errorf("Expected %d rejected attempts, got %+v", maxValidationAttempts, noValid)
+			}
+			if got := spotifyService.randomSearches.Load(); got != maxValidationAttempts {
+				t.Errorf("Expected %d generations, got %d", maxValidationAttempts, got)
+			}
+		})
+	}
+
+	deduped := dedupeArtists([]quizArtist{{ID: "floyd"}, {ID: "gilmour"}, {ID: "floyd"}})
+	if len(deduped) != 2 || deduped[0].ID != "floyd" || deduped[1].ID != "gilmour" {
+		t.Errorf("Expected the duplicate artist to be removed, got %+v", deduped)
+	}
+	if ids := artistIDs(explicit.Album.Artists, 5); !slices.Equal(ids, []string{"floyd"}) {
+		t.Errorf("Expected the duplicate artist id to be removed, got %v", ids)
+	}
+}
+
+func TestAlbumMode(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	answer := fakeTrack("answer", "Money", "dsotm", "1973", "floyd")
+	answer.Album.AlbumType, answer.Album.TotalTracks = "album", 10
+	single := fakeTrack("single", "Money (Single)", "money", "1973", "floyd")
+	single.Album.AlbumType, single.Album.TotalTracks = "single", 2
+	other := fakeTrack("other", "Karma Police", "okc", "1997", "radiohead")
+	other.Album.AlbumType, other.Album.TotalTracks = "album", 12
+	other.PreviewURL = "" // never picked as the answer
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{answer, single, other},
+		[]spotify.Artist{fakeArtist("floyd", "art rock", "progressive rock"), fakeArtist("radiohead", "art rock")},
+	)
+	// the label is only known from the full album
+	album := spotifyService.albums["dsotm"]
+	album.Label = "Harvest"
+	spotifyService.albums["dsotm"] = album
+	quizService := NewService(NewRepository(db), spotifyService, WithModes(ModeAlbum), WithSeed(1))
+	edition := Edition{}.WithMode(ModeAlbum)
+
+	quiz, err := quizService.GetTodaysQuiz(ctx, edition)
+	if err != nil {
+		t.Fatalf("Error generating album quiz: %v", err)
+	}
+	if quiz.Album.ID != "dsotm" || quiz.Album.Label != "Harvest" || quiz.Album.TotalTracks != 10 || quiz.Track.ID != "answer" {
+		t.Fatalf("Expected the studio album of the picked track, got %+v", quiz)
+	}
+
+	clues, err := quizService.GetClues(ctx, quiz.ID, "player")
+	if err != nil {
+		t.Fatalf("Error getting clues: %v", err)
+	}
+	if clues.TotalTracks != 10 || clues.Genres != nil || clues.Label != "" || clues.AlbumImage != "" || clues.Artists != nil {
+		t.Errorf("Expected only the track count before the first guess, got %+v", clues)
+	}
+	if _, err := quizService.Guess(ctx, quiz.ID, "player", Guess{TrackID: "answer"}); !errors.Is(err, ErrEmptyGuess) {
+		t.Errorf("Expected track guesses to be rejected in album mode, got %v", err)
+	}
+
+	result, err := quizService.Guess(ctx, quiz.ID, "player", Guess{AlbumID: "okc"})
+	if err != nil {
+		t.Fatalf("Error checking guess: %v", err)
+	}
+	if result.Correct || result.SameArtist || len(result.SharedArtists) != 0 || !slices.Equal(result.SharedGenres, []string{"art rock"}) || result.ReleaseYear != YearLower {
+		t.Errorf("Expected artists, genres and release year to be compared, got %+v", result.GuessResult)
+	}
+	if result.Clues.Genres == nil || result.Clues.ReleaseYear != "" {
+		t.Errorf("Expected the genres to be unlocked by the first wrong guess, got %+v", result.Clues)
+	}
+
+	result, err = quizService.Guess(ctx, quiz.ID, "player", Guess{AlbumName: "money"})
+	if err != nil || result.Correct || result.AlbumID != "money" || !slices.Equal(result.SharedArtists, []string{"Artist floyd"}) || result.ReleaseYear != YearEqual {
+		t.Fatalf("Expected a typed name to be looked up, got %+v, %v", result.GuessResult, err)
+	}
+
+	result, err = quizService.Guess(ctx, quiz.ID, "player", Guess{AlbumName: "album dsotm"})
+	if err != nil || !result.Correct || result.Clues.Label != "Harvest" || result.Clues.AlbumImage == "" {
+		t.Errorf("Expected a typed name to solve the quiz and reveal the album, got %+v, %v", result, err)
+	}
+
+	share, err := quizService.GetShare(ctx, quiz.ID, "player")
+	if err != nil || !slices.Equal(share.Grid, []string{"⬛⬛🟨⬇️", "⬛🟩🟨🟩", "🟩🟩🟩🟩"}) {
+		t.Errorf("Expected an album grid, got %v, %v", share.Grid, err)
+	}
+}
+
+// silentMP3 returns about the given number of seconds of silent MPEG-1 layer III frames.
+func silentMP3(seconds int) []byte {
+	const frameLength, frameDuration = 417, 1152.0 / 44100
+	var mp3 bytes.Buffer
+	for i := 0; float64(i)*frameDuration < float64(seconds); i++ {
+		frame := make([]byte, frameLength)
+		copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+		mp3.Write(frame)
+	}
+	return mp3.Bytes()
+}
+
+func TestAudioMode(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	previews := map[string][]byte{"/answer": silentMP3(previewSeconds), "/short": silentMP3(5)}
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		w.Write(previews[r.URL.Path])
+	}))
+	defer server.Close()
+
+	answer := fakeTrack("answer", "Wish You Were Here", "wywh", "1975", "floyd")
+	answer.PreviewURL = server.URL + "/answer"
+	short := fakeTrack("short", "Shine On", "shine", "1975", "floyd")
+	short.PreviewURL = server.URL + "/short"
+	wrong := fakeTrack("wrong", "Karma Police", "okc", "1997", "radiohead")
+	wrong.PreviewURL = "" // never picked as the answer
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{answer, short, wrong},
+		[]spotify.Artist{fakeArtist("floyd"), fakeArtist("radiohead")},
+	)
+	// the seed picks the track with a short preview first
+	quizService := NewService(NewRepository(db), spotifyService, WithModes(ModeAudio), WithSeed(6))
+
+	quiz, err := quizService.GetTodaysQuiz(ctx, Edition{}.WithMode(ModeAudio))
+	if err != nil {
+		t.Fatalf("Error generating audio quiz: %v", err)
+	}
+	if quiz.Track.ID != "answer" {
+		t.Fatalf("Expected the track with a short preview to be rejected, got %s", quiz.Track.ID)
+	}
+	classic, err := quizService.GetTodaysQuiz(ctx, Edition{})
+	if err != nil {
+		t.Fatalf("Error generating classic quiz: %v", err)
+	}
+	if _, err := quizService.Skip(ctx, classic.ID, "player"); !errors.Is(err, ErrSkipNotAllowed) {
+		t.Errorf("Expected ErrSkipNotAllowed in classic mode, got %v", err)
+	}
+
+	clues, err := quizService.GetClues(ctx, quiz.ID, "player")
+	if err != nil {
+		t.Fatalf("Error getting clues: %v", err)
+	}
+	if clues.AudioSeconds != 1 || clues.MaxAttempts != 6 || clues.Genres != nil || clues.ReleaseYear != "" || clues.AlbumImage != "" || clues.Artists != nil {
+		t.Errorf("Expected only a 1 second clip before the first try, got %+v", clues)
+	}
+	generated := downloads.Load()
+	clip, err := quizService.GetAudio(ctx, quiz.ID, "player", 0)
+	if err != nil || len(clip.Data) == 0 || clip.Seconds != 1 {
+		t.Fatalf("Expected a 1 second clip, got %d bytes, %v", len(clip.Data), err)
+	}
+	if downloads.Load() != generated {
+		t.Errorf("Expected the preview downloaded by the generation to be cached")
+	}
+
+	expected := []int{2, 4, 7, 11, 16}
+	for i, seconds := range expected {
+		var response GuessResponse
+		if i == 1 {
+			response, err = quizService.Guess(ctx, quiz.ID, "player", Guess{TrackID: "wrong"})
+		} else {
+			response, err = quizService.Skip(ctx, quiz.ID, "player")
+		}
+		if err != nil {
+			t.Fatalf("Error on try %d: %v", i+1, err)
+		}
+		if response.Clues.AudioSeconds != seconds || response.Clues.Solved || response.Clues.GaveUp {
+			t.Errorf("Expected a %d seconds clip after try %d, got %+v", seconds, i+1, response.Clues)
+		}
+	}
+	if _, err := quizService.GetAudio(ctx, quiz.ID, "player", 30); !errors.Is(err, ErrAudioLocked) {
+		t.Errorf("Expected ErrAudioLocked for a longer clip than unlocked, got %v", err)
+	}
+
+	response, err := quizService.Skip(ctx, quiz.ID, "player")
+	if err != nil || !response.Skipped || !response.Clues.GaveUp || response.Clues.AudioSeconds != previewSeconds || response.Clues.Artists == nil {
+		t.Errorf("Expected the last skip to lose the quiz and reveal the track, got %+v, %v", response, err)
+	}
+	if _, err := quizService.Skip(ctx, quiz.ID, "player"); !errors.Is(err, ErrQuizSolved) {
+		t.Errorf("Expected ErrQuizSolved once out of tries, got %v", err)
+	}
+	share, err := quizService.GetShare(ctx, quiz.ID, "player")
+	if err != nil || !share.GaveUp || !slices.Equal(share.Grid, []string{"⬜", "⬛", "⬜", "⬜", "⬜", "⬜"}) {
+		t.Errorf("Expected an audio grid of the lost quiz, got %+v, %v", share, err)
+	}
+
+	quizService.Skip(ctx, quiz.ID, "another player")
+	response, err = quizService.Guess(ctx, quiz.ID, "another player", Guess{TrackName: "wish you were here"})
+	if err != nil || !response.Correct || response.Clues.AudioSeconds != previewSeconds {
+		t.Errorf("Expected the quiz to be solved after a skip, got %+v, %v", response, err)
+	}
+}
+
+func TestCustomQuiz(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	noImage := fakeTrack("noimage", "Echoes", "meddle", "1971", "floyd")
+	noImage.Album.Images = nil
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd"),
+			fakeTrack("b", "Karma Police", "okc", "1997", "radiohead"),
+			fakeTrack("c", "Wonderwall", "morning", "1995", "oasis"),
+			noImage,
+		},
+		[]spotify.Artist{fakeArtist("floyd", "progressive rock"), fakeArtist("radiohead", "art rock"), fakeArtist("oasis", "britpop")},
+	)
+	quizService := NewService(NewRepository(db), spotifyService)
+
+	invalid := []struct {
+		name string
+		req  CustomQuizRequest
+	}{
+		{"no title", CustomQuizRequest{Title: " ", TrackIDs: []string{"a"}}},
+		{"no tracks", CustomQuizRequest{Title: "Mix"}},
+		{"unknown track", CustomQuizRequest{Title: "Mix", TrackIDs: []string{"a", "nope"}}},
+		{"too many rounds", CustomQuizRequest{Title: "Mix", TrackIDs: []string{"a"}, Rounds: 2}},
+		{"artist mode", CustomQuizRequest{Title: "Mix", TrackIDs: []string{"a"}, Mode: ModeArtist}},
+		{"unknown hint", CustomQuizRequest{Title: "Mix", TrackIDs: []string{"a"}, Hints: []string{"lyrics"}}},
+		{"repeated hint", CustomQuizRequest{Title: "Mix", TrackIDs: []string{"a"}, Hints: []string{"genres", "genres"}}},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := quizService.CreateCustomQuiz(ctx, tc.req); !errors.Is(err, ErrInvalidCustomQuiz) {
+				t.Errorf("Expected ErrInvalidCustomQuiz, got %v", err)
+			}
+		})
+	}
+	_, err = quizService.CreateCustomQuiz(ctx, CustomQuizRequest{Title: "Mix", TrackIDs: []string{"a", "noimage"}})
+	if !errors.Is(err, ErrInvalidCustomQuiz) || !errors.Is(err, ErrMissingImage) {
+		t.Errorf("Expected the track without image to be rejected, got %v", err)
+	}
+
+	custom, err := quizService.CreateCustomQuiz(ctx, CustomQuizRequest{
+		Title:    " Friday Mix ",
+		TrackIDs: []string{"a", "b", "c", "a"},
+		Rounds:   2,
+		Hints:    []string{"artists", "genres"},
+	})
+	if err != nil {
+		t.Fatalf("Error creating custom quiz: %v", err)
+	}
+	if len(custom.Code) != shareCodeLength || custom.Title != "Friday Mix" || custom.Mode != ModeClassic ||
+		!slices.Equal(custom.Rounds, []string{customPrefix + custom.Code + "-1", customPrefix + custom.Code + "-2"}) {
+		t.Fatalf("Expected a custom quiz with 2 rounds, got %+v", custom)
+	}
+	if shared, err := quizService.GetCustomQuiz(ctx, custom.Code); err != nil || !slices.Equal(shared.Rounds, custom.Rounds) {
+		t.Errorf("Expected the custom quiz to be found by its code, got %+v, %v", shared, err)
+	}
+	if _, err := quizService.GetCustomQuiz(ctx, "unknown"); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("Expected ErrQuizNotFound for an unknown code, got %v", err)
+	}
+
+	round, err := quizService.GetQuiz(ctx, custom.Rounds[0])
+	if err != nil {
+		t.Fatalf("Error getting round: %v", err)
+	}
+	wrong := "a"
+	if round.Track.ID == "a" {
+		wrong = "b"
+	}
+
+	clues, err := quizService.GetClues(ctx, round.ID, "player")
+	if err != nil || clues.Title != "Friday Mix" || clues.Round != 1 || clues.Genres != nil || clues.Artists != nil {
+		t.Errorf("Expected the first round without hints, got %+v, %v", clues, err)
+	}
+	response, err := quizService.Guess(ctx, round.ID, "player", Guess{TrackID: wrong})
+	if err != nil || response.Clues.Artists == nil || response.Clues.Genres != nil {
+		t.Errorf("Expected the artists to be the first hint, got %+v, %v", response.Clues, err)
+	}
+	response, err = quizService.Guess(ctx, round.ID, "player", Guess{TrackID: wrong})
+	if err != nil || response.Clues.Genres == nil || response.Clues.ReleaseYear != "" || response.Clues.AlbumImage != "" {
+		t.Errorf("Expected only the hints of the custom ladder, got %+v, %v", response.Clues, err)
+	}
+	response, err = quizService.Guess(ctx, round.ID, "player", Guess{TrackID: round.Track.ID})
+	if err != nil || !response.Correct {
+		t.Fatalf("Expected the round to be solved, got %+v, %v", response, err)
+	}
+
+	share, err := quizService.GetShare(ctx, round.ID, "player")
+	if err != nil || !strings.HasPrefix(share.Text, "Spotifydle Friday Mix, round 1 🎵 3 guesses") {
+		t.Errorf("Expected the title of the custom quiz in the share text, got %q, %v", share.Text, err)
+	}
+
+	// a custom quiz drawing the share code of another one gets a new code
+	rounds := []Quiz{round}
+	rounds[0].ID = customRoundID(custom.Code, 1)
+	taken := CustomQuiz{Code: custom.Code, Title: "Taken", Rounds: []string{rounds[0].ID}, CreatedAt: time.Now()}
+	stored, err := quizService.storeCustomQuiz(ctx, taken, rounds)
+	if err != nil || stored.Code == custom.Code || !slices.Equal(stored.Rounds, []string{customRoundID(stored.Code, 1)}) {
+		t.Fatalf("Expected the custom quiz to be stored with a new code, got %+v, %v", stored, err)
+	}
+	if shared, err := quizService.GetCustomQuiz(ctx, custom.Code); err != nil || shared.Title != "Friday Mix" {
+		t.Errorf("Expected the custom quiz first stored with the code to be kept, got %+v, %v", shared, err)
+	}
+	if _, err := quizService.GetQuiz(ctx, stored.Rounds[0]); err != nil {
+		t.Errorf("Expected the round to be stored under the new code, got %v", err)
+	}
+}
+
+func TestPlaylistQuiz(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	noPreview := fakeTrack("nopreview", "Time", "dsotm", "1973", "floyd")
+	noPreview.PreviewURL = ""
+	noImage := fakeTrack("noimage", "Echoes", "meddle", "1971", "floyd")
+	noImage.Album.Images = nil
+	tracks := []spotify.Track{
+		fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd"),
+		fakeTrack("b", "Karma Police", "okc", "1997", "radiohead"),
+		fakeTrack("c", "Wonderwall", "morning", "1995", "oasis"),
+		noPreview,
+		noImage,
+	}
+	spotifyService := newFakeSpotifyService(tracks,
+		[]spotify.Artist{fakeArtist("floyd", "progressive rock"), fakeArtist("radiohead", "art rock"), fakeArtist("oasis", "britpop")},
+	)
+	const playlistID = "37i9dQZF1DXcBWIGoYBM5M"
+	spotifyService.playlists[playlistID] = append(tracks, tracks[0])
+	spotifyService.playlists["3cEYpjA9oz9GiPac4AsH4n"] = []spotify.Track{noPreview}
+	quizService := NewService(NewRepository(db), spotifyService)
+
+	for _, playlist := range []string{
+		playlistID,
+		"spotify:playlist:" + playlistID,
+		"https://open.spotify.com/playlist/" + playlistID + "?si=4f2a",
+		"https://open.spotify.com/intl-fr/playlist/" + playlistID,
+	} {
+		if id, err := parsePlaylistID(playlist); err != nil || id != playlistID {
+			t.Errorf("Expected %q to be parsed as %s, got %q, %v", playlist, playlistID, id, err)
+		}
+	}
+	for _, playlist := range []string{"", "hits", "https://example.com/playlist/" + playlistID, "https://open.spotify.com/album/" + playlistID} {
+		if _, err := parsePlaylistID(playlist); !errors.Is(err, ErrInvalidPlaylist) {
+			t.Errorf("Expected ErrInvalidPlaylist for %q, got %v", playlist, err)
+		}
+	}
+
+	if _, err := quizService.NewPlaylistQuiz(ctx, PlaylistQuizRequest{Playlist: "0000000000000000000000"}); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Errorf("Expected ErrPlaylistNotFound for an unknown playlist, got %v", err)
+	}
+	if _, err := quizService.NewPlaylistQuiz(ctx, PlaylistQuizRequest{Playlist: "3cEYpjA9oz9GiPac4AsH4n"}); !errors.Is(err, ErrInvalidCustomQuiz) {
+		t.Errorf("Expected ErrInvalidCustomQuiz for a playlist without previews, got %v", err)
+	}
+	// the track without image is skipped, leaving 3 playable tracks
+	if _, err := quizService.NewPlaylistQuiz(ctx, PlaylistQuizRequest{Playlist: playlistID, Rounds: 4}); !errors.Is(err, ErrInvalidCustomQuiz) {
+		t.Errorf("Expected ErrInvalidCustomQuiz for more rounds than playable tracks, got %v", err)
+	}
+
+	custom, err := quizService.NewPlaylistQuiz(ctx, PlaylistQuizRequest{Playlist: "https://open.spotify.com/playlist/" + playlistID})
+	if err != nil {
+		t.Fatalf("Error creating playlist quiz: %v", err)
+	}
+	if custom.Title != defaultPlaylistTitle || custom.Mode != ModeClassic || len(custom.Rounds) != 3 {
+		t.Fatalf("Expected a playlist quiz with every playable track, got %+v", custom)
+	}
+	played := []string{}
+	for i, id := range custom.Rounds {
+		round, err := quizService.GetQuiz(ctx, id)
+		if err != nil || round.Round != i+1 {
+			t.Fatalf("Expected round %d to be found, got %+v, %v", i+1, round, err)
+		}
+		if slices.Contains(played, round.Track.ID) || round.Track.ID == "nopreview" || round.Track.ID == "noimage" {
+			t.Errorf("Expected each playable track once, got %s after %v", round.Track.ID, played)
+		}
+		played = append(played, round.Track.ID)
+	}
+}
+
+func TestQuizSession(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd"),
+			fakeTrack("b", "Karma Police", "okc", "1997", "radiohead"),
+		},
+		[]spotify.Artist{fakeArtist("floyd", "progressive rock"), fakeArtist("radiohead", "art rock")},
+	)
+	now := time.Date(2024, time.October, 5, 12, 0, 0, 0, time.UTC)
+	quizService := NewService(NewRepository(db), spotifyService, WithClock(func() time.Time { return now }))
+
+	if _, err := quizService.NewQuizSession(ctx, Edition{}, "player", maxSessionRounds+1); !errors.Is(err, ErrInvalidRounds) {
+		t.Errorf("Expected ErrInvalidRounds, got %v", err)
+	}
+	if _, err := quizService.NewQuizSession(ctx, Edition{Category: "nope"}, "player", 2); !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("Expected ErrCategoryNotFound, got %v", err)
+	}
+
+	quizSession, err := quizService.NewQuizSession(ctx, Edition{}, "player", 2)
+	if err != nil {
+		t.Fatalf("Error creating quiz session: %v", err)
+	}
+	if _, err := quizService.GetQuizSession(ctx, quizSession.ID, "someone else"); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("Expected the quiz session to be hidden from other players, got %v", err)
+	}
+
+	clues, err := quizService.NextRound(ctx, quizSession.ID, "player")
+	if err != nil || clues.Round != 1 {
+		t.Fatalf("Expected the first round, got %+v, %v", clues, err)
+	}
+	now = now.Add(10 * time.Second)
+	if again, err := quizService.NextRound(ctx, quizSession.ID, "player"); err != nil || again.ID != clues.ID {
+		t.Errorf("Expected the unfinished round to be served again, got %s, %v", again.ID, err)
+	}
+
+	round, err := quizService.GetQuiz(ctx, clues.ID)
+	if err != nil {
+		t.Fatalf("Error getting round: %v", err)
+	}
+	wrong := "a"
+	if round.Track.ID == "a" {
+		wrong = "b"
+	}
+	if _, err := quizService.Guess(ctx, clues.ID, "player", Guess{TrackID: wrong}); err != nil {
+		t.Fatalf("Error guessing: %v", err)
+	}
+	now = now.Add(20 * time.Second)
+	if _, err := quizService.Guess(ctx, clues.ID, "player", Guess{TrackID: round.Track.ID}); err != nil {
+		t.Fatalf("Error guessing: %v", err)
+	}
+
+	clues, err = quizService.NextRound(ctx, quizSession.ID, "player")
+	if err != nil || clues.Round != 2 {
+		t.Fatalf("Expected the second round, got %+v, %v", clues, err)
+	}
+	if _, err := quizService.GiveUp(ctx, clues.ID, "player"); err != nil {
+		t.Fatalf("Error giving up: %v", err)
+	}
+	if _, err := quizService.NextRound(ctx, quizSession.ID, "player"); !errors.Is(err, ErrQuizSessionOver) {
+		t.Errorf("Expected ErrQuizSessionOver after the last round, got %v", err)
+	}
+
+	breakdown, err := quizService.GetQuizSession(ctx, quizSession.ID, "player")
+	if err != nil {
+		t.Fatalf("Error getting quiz session: %v", err)
+	}
+	// solved with 2 attempts 30 seconds after the round was served
+	first := breakdown.Rounds[0]
+	if !first.Solved || first.Attempts != 2 || first.TimeMs != 30_000 ||
+		first.AttemptPoints != maxAttemptPoints-attemptPenalty || first.TimeBonus != maxTimeBonus*2/3 {
+		t.Errorf("Expected the points of the first round, got %+v", first)
+	}
+	if second := breakdown.Rounds[1]; second.Solved || !second.Finished || second.Points != 0 {
+		t.Errorf("Expected no points for the round given up, got %+v", second)
+	}
+	if !breakdown.Finished || breakdown.Score != first.Points {
+		t.Errorf("Expected a finished quiz session scoring %d, got %+v", first.Points, breakdown)
+	}
+}
+
+func TestQuizSessionConcurrently(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{
+			fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd"),
+			fakeTrack("b", "Karma Police", "okc", "1997", "radiohead"),
+		},
+		[]spotify.Artist{fakeArtist("floyd", "progressive rock"), fakeArtist("radiohead", "art rock")},
+	)
+	spotifyService.delay = 50 * time.Millisecond
+	quizService := NewService(NewRepository(db), spotifyService)
+
+	quizSession, err := quizService.NewQuizSession(ctx, Edition{}, "player", 2)
+	if err != nil {
+		t.Fatalf("Error creating quiz session: %v", err)
+	}
+
+	// parallel requests can't be used to pick among several rounds
+	var wg sync.WaitGroup
+	rounds := make([]Clues, 5)
+	for i := range rounds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clues, err := quizService.NextRound(ctx, quizSession.ID, "player")
+			if err != nil {
+				t.Errorf("Error serving the next round: %v", err)
+			}
+			rounds[i] = clues
+		}()
+	}
+	wg.Wait()
+
+	for _, round := range rounds {
+		if round.ID != rounds[0].ID || round.Round != 1 {
+			t.Errorf("Expected every request to get the first round, got round %d %s and %s", round.Round, round.ID, rounds[0].ID)
+		}
+	}
+	quizSession, err = quizService.GetQuizSession(ctx, quizSession.ID, "player")
+	if err != nil || len(quizSession.Rounds) != 1 {
+		t.Errorf("Expected a single round to be served, got %+v, %v", quizSession.Rounds, err)
+	}
+}
+
+func TestQuizSessionExpiry(t *testing.T) {
+	ctx := context.Background()
+	db, err := db.NewSQLiteDB(ctx, ":memory:")
+	if err != nil {
+		log.Fatalf("error connecting to in memory db: %v", err)
+	}
+	defer db.Close()
+
+	spotifyService := newFakeSpotifyService(
+		[]spotify.Track{fakeTrack("a", "Wish You Were Here", "wywh", "1975", "floyd")},
+		[]spotify.Artist{fakeArtist("floyd", "progressive rock")},
+	)
+	quizService := NewService(NewRepository(db), spotifyService, WithPracticeTTL(200*time.Millisecond))
+
+	quizSession, err := quizService.NewQuizSession(ctx, Edition{}, "player", 2)
+	if err != nil {
+		t.Fatalf("Error creating quiz session: %v", err)
+	}
+	clues, err := quizService.NextRound(ctx, quizSession.ID, "player")
+	if err != nil {
+		t.Fatalf("Error serving the first round: %v", err)
+	}
+	if _, err := quizService.Guess(ctx, clues.ID, "player", Guess{TrackID: "a"}); err != nil {
+		t.Fatalf("Error guessing: %v", err)
+	}
+
+	// past the lifetime of a practice quiz, but not of the quiz session of 2 rounds
+	time.Sleep(250 * time.Millisecond)
+	if _, err := quizService.GetQuiz(ctx, clues.ID); err != nil {
+		t.Errorf("Expected the round to last as long as its quiz session, got %v", err)
+	}
+	breakdown, err := quizService.GetQuizSession(ctx, quizSession.ID, "player")
+	if err != nil {
+		t.Fatalf("Error getting quiz session: %v", err)
+	}
+	if first := breakdown.Rounds[0]; !first.Finished || !first.Solved || first.Attempts != 1 || first.Points == 0 || breakdown.Score != first.Points {
+		t.Errorf("Expected the result of the first round to be kept, got %+v", breakdown)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if _, err := quizService.GetQuizSession(ctx, quizSession.ID, "player"); !errors.Is(err, ErrQuizNotFound) {
+		t.Errorf("Expected the quiz session to expire, got %v", err)
+	}
+}
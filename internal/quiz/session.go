@@ -0,0 +1,289 @@
+package quiz
+
+import (
+	"backendProject/internal/db"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+)
+
+const (
+	defaultSessionRounds = 5
+	maxSessionRounds     = 20
+
+	// maxAttemptPoints are awarded for a round solved on the first guess,
+	// each further guess or skip costing attemptPenalty down to minAttemptPoints.
+	maxAttemptPoints = 1000
+	attemptPenalty   = 150
+	minAttemptPoints = 100
+	// maxTimeBonus is added for a round solved at once, decreasing
+	// linearly to nothing once the round took timeBonusWindow.
+	maxTimeBonus    = 500
+	timeBonusWindow = 90 * time.Second
+)
+
+// QuizSession is a game of several rounds played in a row by a player, like a marathon.
+// Each round is a practice quiz generated when the player moves on to it and played
+// through the same APIs as the daily quiz, so any game made of rounds can reuse it.
+// Rounds are scored with server timestamps only, from when the round was served to
+// when its quiz was solved or given up, and the result of each round is kept on the
+// quiz session once the round is finished. Quiz sessions are only found by their player,
+// and their rounds can be played as long as the quiz session.
+type QuizSession struct {
+	ID string `json:"id"`
+	Edition
+	Rounds     []SessionRound `json:"rounds"` // served so far, in order
+	RoundCount int            `json:"round_count"`
+	Score      int            `json:"score"`
+	Finished   bool           `json:"finished"` // whether every round was served and finished
+	StartedAt  time.Time      `json:"started_at"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+}
+
+// SessionRound is the breakdown of the points of a round of a quiz session.
+type SessionRound struct {
+	Round         int        `json:"round"` // from 1
+	QuizID        string     `json:"quiz_id"`
+	Attempts      int        `json:"attempts"`
+	Solved        bool       `json:"solved"`
+	Finished      bool       `json:"finished"`
+	AttemptPoints int        `json:"attempt_points"`
+	TimeBonus     int        `json:"time_bonus"`
+	Points        int        `json:"points"`
+	TimeMs        int64      `json:"time_ms,omitempty"` // from the round being served to its solve
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// NewQuizSession starts a quiz session of several rounds for a player. The rounds are
+// generated one at a time when the player moves on to them. (see NextRound)
+//
+// Parameters:
+//   - edition: The category, difficulty and mode of the rounds.
+//   - playerID: The ID of the player.
+//   - rounds: The number of rounds, defaultSessionRounds when 0.
+//
+// Returns:
+//   - A QuizSession object without any round served yet.
+//   - An error if the number of rounds is invalid, the category, difficulty or mode doesn't exist
+//     or the session can't be stored.
+func (s *service) NewQuizSession(ctx context.Context, edition Edition, playerID string, rounds int) (QuizSession, error) {
+	if rounds == 0 {
+		rounds = defaultSessionRounds
+	}
+	if rounds < 0 || rounds > maxSessionRounds {
+		return QuizSession{}, ErrInvalidRounds
+	}
+	if _, err := s.category(edition); err != nil {
+		return QuizSession{}, err
+	}
+
+	now := s.now()
+	quizSession := QuizSession{
+		ID:         newID(),
+		Edition:    edition,
+		Rounds:     []SessionRound{},
+		RoundCount: rounds,
+		StartedAt:  now,
+		// each round gets as long as a practice quiz
+		ExpiresAt: now.Add(time.Duration(rounds) * s.practiceTTL),
+	}
+	if err := s.setQuizSession(ctx, playerID, quizSession); err != nil {
+		return QuizSession{}, err
+	}
+	return quizSession, nil
+}
+
+// NextRound serves the next round of a quiz session, once the current one is finished.
+// The current round is served again while it isn't finished, so its timer is never reset.
+// Concurrent calls are served one at a time, so they all get the same round.
+//
+// Parameters:
+//   - id: The ID of the quiz session.
+//   - playerID: The ID of the player.
+//
+// Returns:
+//   - A Clues object for the round, with the ID of its quiz used to send guesses.
+//   - An error if the quiz session is not found, every round was already played or the quiz generation fails.
+func (s *service) NextRound(ctx context.Context, id, playerID string) (Clues, error) {
+	for {
+		unlock, err := s.repository.LockQuizSession(ctx, playerID, id, generationLockTTL)
+		if err == nil {
+			defer unlock(ctx)
+			break
+		}
+		if !errors.Is(err, db.ErrLockNotAcquired) {
+			log.Printf("Error locking quiz session %s: %v", id, err)
+			return Clues{}, err
+		}
+		// another call is serving a round, which is served again once it's stored
+		time.Sleep(generationPollInterval)
+	}
+
+	quizSession, err := s.getQuizSession(ctx, id, playerID)
+	if err != nil {
+		return Clues{}, err
+	}
+
+	if n := len(quizSession.Rounds); n > 0 && !quizSession.Rounds[n-1].Finished {
+		quiz, session, err := s.roundSession(ctx, quizSession.Rounds[n-1], playerID)
+		if err != nil {
+			return Clues{}, err
+		}
+		if !session.Finished() {
+			return s.roundClues(quiz, session, n), nil
+		}
+		// the result of the round failed to be recorded when it finished
+		quizSession.Rounds[n-1] = scoreRound(quizSession.Rounds[n-1], session)
+		if err := s.setQuizSession(ctx, playerID, quizSession); err != nil {
+			return Clues{}, err
+		}
+	}
+	if len(quizSession.Rounds) == quizSession.RoundCount {
+		return Clues{}, ErrQuizSessionOver
+	}
+
+	// rounds are played as long as their quiz session, however late they are served
+	ttl := max(quizSession.ExpiresAt.Sub(s.now()), time.Millisecond)
+	quiz, err := s.newPracticeQuiz(ctx, quizSession.Edition, playerID, ttl, quizSession.ID)
+	if err != nil {
+		return Clues{}, err
+	}
+	quizSession.Rounds = append(quizSession.Rounds, SessionRound{
+		Round:     len(quizSession.Rounds) + 1,
+		QuizID:    quiz.ID,
+		StartedAt: s.now(),
+	})
+	if err := s.setQuizSession(ctx, playerID, quizSession); err != nil {
+		return Clues{}, err
+	}
+
+	session, err := s.getSession(ctx, playerID, quiz)
+	if err != nil {
+		return Clues{}, err
+	}
+	return s.roundClues(quiz, session, len(quizSession.Rounds)), nil
+}
+
+// GetQuizSession returns a quiz session along with the points of each round finished so far,
+// which make up the final breakdown once every round is finished.
+//
+// Parameters:
+//   - id: The ID of the quiz session.
+//   - playerID: The ID of the player.
+//
+// Returns:
+//   - A QuizSession object containing the points of each round and the total score.
+//   - An error if the quiz session is not found or a round can't be read.
+func (s *service) GetQuizSession(ctx context.Context, id, playerID string) (QuizSession, error) {
+	quizSession, err := s.getQuizSession(ctx, id, playerID)
+	if err != nil {
+		return QuizSession{}, err
+	}
+
+	finished := len(quizSession.Rounds) == quizSession.RoundCount
+	for _, round := range quizSession.Rounds {
+		quizSession.Score += round.Points
+		finished = finished && round.Finished
+	}
+	quizSession.Finished = finished
+	return quizSession, nil
+}
+
+// recordRound keeps the result of a finished round on its quiz session, so the
+// breakdown never depends on the quiz of the round, which is only played once.
+//
+// Parameters:
+//   - quiz: The quiz played, only recorded when it's a round of a quiz session.
+//   - session: The session of the player on the quiz, after the guess.
+//
+// Returns:
+//   - An error if the quiz session can't be read or stored.
+func (s *service) recordRound(ctx context.Context, quiz Quiz, session Session) error {
+	if quiz.QuizSessionID == "" || !session.Finished() {
+		return nil
+	}
+
+	quizSession, err := s.getQuizSession(ctx, quiz.QuizSessionID, session.ID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(quizSession.Rounds, func(round SessionRound) bool { return round.QuizID == quiz.ID })
+	if i == -1 || quizSession.Rounds[i].Finished {
+		return nil
+	}
+	quizSession.Rounds[i] = scoreRound(quizSession.Rounds[i], session)
+	return s.setQuizSession(ctx, session.ID, quizSession)
+}
+
+// scoreRound awards the points of a round from the session of its quiz. Only solved
+// rounds score, fewer attempts and a faster solve scoring more.
+//
+// Parameters:
+//   - round: The round, with the time it was served.
+//   - session: The player's session on the quiz of the round.
+//
+// Returns:
+//   - The round with its points.
+func scoreRound(round SessionRound, session Session) SessionRound {
+	round.Attempts = len(session.Guesses)
+	round.Solved = session.Solved
+	round.Finished = session.Finished()
+	round.FinishedAt = session.FinishedAt
+	if !session.Solved || session.FinishedAt == nil {
+		return round
+	}
+
+	elapsed := max(session.FinishedAt.Sub(round.StartedAt), 0)
+	round.TimeMs = elapsed.Milliseconds()
+	round.AttemptPoints = max(maxAttemptPoints-(round.Attempts-1)*attemptPenalty, minAttemptPoints)
+	if elapsed < timeBonusWindow {
+		round.TimeBonus = int(maxTimeBonus * (timeBonusWindow - elapsed) / timeBonusWindow)
+	}
+	round.Points = round.AttemptPoints + round.TimeBonus
+	return round
+}
+
+// roundSession returns the quiz of a round along with the player's session on it.
+func (s *service) roundSession(ctx context.Context, round SessionRound, playerID string) (Quiz, Session, error) {
+	quiz, err := s.GetQuiz(ctx, round.QuizID)
+	if err != nil {
+		return Quiz{}, Session{}, err
+	}
+	session, err := s.getSession(ctx, playerID, quiz)
+	if err != nil {
+		return Quiz{}, Session{}, err
+	}
+	return quiz, session, nil
+}
+
+// roundClues returns the clues of the quiz of a round, numbered within its quiz session.
+func (s *service) roundClues(quiz Quiz, session Session, round int) Clues {
+	clues := s.clues(quiz, session)
+	clues.Round = round
+	return clues
+}
+
+// getQuizSession returns the quiz session of a player with the given ID, until it expires.
+func (s *service) getQuizSession(ctx context.Context, id, playerID string) (QuizSession, error) {
+	quizSession, err := s.repository.GetQuizSession(ctx, playerID, id)
+	if err != nil {
+		log.Printf("Error getting quiz session %s: %v", id, err)
+		return QuizSession{}, err
+	}
+	if quizSession.StartedAt.IsZero() {
+		return QuizSession{}, ErrQuizNotFound
+	}
+	return quizSession, nil
+}
+
+// setQuizSession stores the quiz session of a player until it expires.
+func (s *service) setQuizSession(ctx context.Context, playerID string, quizSession QuizSession) error {
+	err := s.repository.SetQuizSession(ctx, playerID, quizSession, max(quizSession.ExpiresAt.Sub(s.now()), time.Millisecond))
+	if err != nil {
+		log.Printf("Error setting quiz session %s: %v", quizSession.ID, err)
+	}
+	return err
+}
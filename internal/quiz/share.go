@@ -0,0 +1,340 @@
+package quiz
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// defaultShareTitle names the game in the shared results, as the project is named.
+const defaultShareTitle = "Spotifydle"
+
+// Feedback of each attribute of a guess in the shared results grid.
+const (
+	cellMiss = iota
+	cellPartial
+	cellHit
+	cellHigher
+	cellLower
+	cellSkip
+)
+
+var cellEmojis = map[int]string{
+	cellMiss:    "⬛",
+	cellPartial: "🟨",
+	cellHit:     "🟩",
+	cellHigher:  "⬆️",
+	cellLower:   "⬇️",
+	cellSkip:    "⬜",
+}
+
+// Share is the spoiler free summary of a finished session, to be pasted
+// in group chats. It never contains the answer nor the guessed tracks.
+type Share struct {
+	QuizID     string   `json:"quiz_id"`
+	Number     int      `json:"number"`
+	Category   string   `json:"category,omitempty"`
+	Difficulty string   `json:"difficulty,omitempty"`
+	Mode       string   `json:"mode,omitempty"`
+	Title      string   `json:"title,omitempty"` // of the custom quiz played
+	Round      int      `json:"round,omitempty"`
+	Attempts   int      `json:"attempts"`
+	GaveUp     bool     `json:"gave_up,omitempty"`
+	Grid       []string `json:"grid"` // one row of emojis per guess
+	Text       string   `json:"text"`
+
+	cells [][]int // feedback of each guess, to render the card
+	title string  // name of the game, heading the results
+}
+
+// GetShare returns the shareable results of a session, once the quiz is solved or given up.
+//
+// Parameters:
+//   - quizID: The ID of the quiz played.
+//   - sessionID: The ID of the player's session.
+//
+// Returns:
+//   - A Share object containing the share text and the emoji grid.
+//   - An error if the quiz or the session can't be retrieved, or the quiz isn't finished yet.
+func (s *service) GetShare(ctx context.Context, quizID, sessionID string) (Share, error) {
+	quiz, err := s.GetQuiz(ctx, quizID)
+	if err != nil {
+		return Share{}, err
+	}
+
+	session, err := s.getSession(ctx, sessionID, quiz)
+	if err != nil {
+		return Share{}, err
+	}
+	if !session.Finished() {
+		return Share{}, ErrQuizNotFinished
+	}
+
+	return newShare(s.shareTitle, quiz, session), nil
+}
+
+// newShare builds the shareable results of a finished session, headed by the name of the game.
+func newShare(title string, quiz Quiz, session Session) Share {
+	share := Share{
+		QuizID:   quiz.ID,
+		Number:   quiz.Number,
+		Category: quiz.Category,
+		Mode:     normalizeMode(quiz.Mode),
+		Title:    quiz.Title,
+		Round:    quiz.Round,
+		Attempts: len(session.Guesses),
+		GaveUp:   session.GaveUp,
+		Grid:     []string{},
+		title:    title,
+	}
+	if quiz.Difficulty != DifficultyNormal {
+		share.Difficulty = quiz.Difficulty
+	}
+
+	for _, guess := range session.Guesses {
+		cells := guessCells(share.Mode, guess)
+		share.cells = append(share.cells, cells)
+
+		var row strings.Builder
+		for _, cell := range cells {
+			row.WriteString(cellEmojis[cell])
+		}
+		share.Grid = append(share.Grid, row.String())
+	}
+
+	guesses := "guesses"
+	if share.Attempts == 1 {
+		guesses = "guess"
+	}
+	result := fmt.Sprintf("%d %s", share.Attempts, guesses)
+	if share.GaveUp {
+		result = "gave up after " + result
+	}
+	share.Text = fmt.Sprintf("%s 🎵 %s\n\n%s", share.heading(), result, strings.Join(share.Grid, "\n"))
+	return share
+}
+
+// heading names the quiz of the results, e.g. "Spotifydle #412 (90s, hard, cover)"
+// or "Spotifydle Friday Mix, round 2 (audio)" for a custom quiz.
+func (s Share) heading() string {
+	heading := s.title + " Practice"
+	switch {
+	case s.Number > 0:
+		heading = fmt.Sprintf("%s #%d", s.title, s.Number)
+	case s.Title != "":
+		heading = fmt.Sprintf("%s %s, round %d", s.title, s.Title, s.Round)
+	}
+
+	var details []string
+	if s.Category != "" {
+		details = append(details, s.Category)
+	}
+	if s.Difficulty != "" {
+		details = append(details, s.Difficulty)
+	}
+	if s.Mode != "" {
+		details = append(details, s.Mode)
+	}
+	if len(details) > 0 {
+		heading += " (" + strings.Join(details, ", ") + ")"
+	}
+	return heading
+}
+
+// guessCells returns the feedback of a guess in the order of the grid
+// columns: title, artist, album, genres and release year, or name,
+// genres and followers in artist mode, or name, artists, genres and
+// release year in album mode, or a single cell in audio mode.
+func guessCells(mode string, guess GuessResult) []int {
+	switch mode {
+	case ModeAudio:
+		return audioCells(guess)
+	case ModeArtist:
+		return artistCells(guess)
+	case ModeAlbum:
+		return albumCells(guess)
+	}
+	if guess.Correct {
+		return []int{cellHit, cellHit, cellHit, cellHit, cellHit}
+	}
+
+	cells := []int{cellMiss, cellMiss, cellMiss, cellMiss, cellMiss}
+	if guess.Close {
+		cells[0] = cellPartial
+	}
+	if guess.SameArtist {
+		cells[1] = cellHit
+	}
+	if guess.SameAlbum {
+		cells[2] = cellHit
+	}
+	if len(guess.SharedGenres) > 0 {
+		cells[3] = cellPartial
+	}
+	switch guess.ReleaseYear {
+	case YearEqual:
+		cells[4] = cellHit
+	case YearHigher:
+		cells[4] = cellHigher
+	case YearLower:
+		cells[4] = cellLower
+	}
+	return cells
+}
+
+// artistCells returns the feedback of a guess in artist mode.
+func artistCells(guess GuessResult) []int {
+	if guess.Correct {
+		return []int{cellHit, cellHit, cellHit}
+	}
+
+	cells := []int{cellMiss, cellMiss, cellMiss}
+	if guess.Close {
+		cells[0] = cellPartial
+	}
+	if len(guess.SharedGenres) > 0 {
+		cells[1] = cellPartial
+	}
+	switch guess.Followers {
+	case FollowersEqual:
+		cells[2] = cellHit
+	case FollowersHigher:
+		cells[2] = cellHigher
+	case FollowersLower:
+		cells[2] = cellLower
+	}
+	return cells
+}
+
+// albumCells returns the feedback of a guess in album mode.
+func albumCells(guess GuessResult) []int {
+	if guess.Correct {
+		return []int{cellHit, cellHit, cellHit, cellHit}
+	}
+
+	cells := []int{cellMiss, cellMiss, cellMiss, cellMiss}
+	if guess.Close {
+		cells[0] = cellPartial
+	}
+	if guess.SameArtist {
+		cells[1] = cellHit
+	}
+	if len(guess.SharedGenres) > 0 {
+		cells[2] = cellPartial
+	}
+	switch guess.ReleaseYear {
+	case YearEqual:
+		cells[3] = cellHit
+	case YearHigher:
+		cells[3] = cellHigher
+	case YearLower:
+		cells[3] = cellLower
+	}
+	return cells
+}
+
+// audioCells returns the feedback of a guess in audio mode, where
+// a wrong guess by the same artist is partially right.
+func audioCells(guess GuessResult) []int {
+	switch {
+	case guess.Correct:
+		return []int{cellHit}
+	case guess.Skipped:
+		return []int{cellSkip}
+	case guess.Close, guess.SameArtist:
+		return []int{cellPartial}
+	default:
+		return []int{cellMiss}
+	}
+}
+
+// Size and colors of the share card, matching the usual social preview size.
+const (
+	cardWidth   = 1200
+	cardHeight  = 630
+	cardPadding = 60
+	cellSize    = 64
+	cellGap     = 12
+	textScale   = 6
+	smallScale  = 5
+	maxCardRows = 6 // guesses drawn on the card, the last ones being kept
+)
+
+var (
+	cardBackground = color.RGBA{0x12, 0x12, 0x12, 0xff}
+	cardText       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	cellColors     = map[int]color.RGBA{
+		cellMiss:    {0x3a, 0x3a, 0x3c, 0xff},
+		cellPartial: {0xb5, 0x9f, 0x3b, 0xff},
+		cellHit:     {0x1d, 0xb9, 0x54, 0xff},
+		cellHigher:  {0x3b, 0x6e, 0xb5, 0xff},
+		cellLower:   {0x3b, 0x6e, 0xb5, 0xff},
+		cellSkip:    {0x81, 0x83, 0x84, 0xff},
+	}
+)
+
+// Image renders the results as a PNG friendly card for social previews,
+// with the heading, the number of guesses and the grid of the last guesses.
+//
+// Returns:
+//   - An image of the card.
+func (s Share) Image() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(cardBackground), image.Point{}, draw.Src)
+
+	heading := fmt.Sprintf("%s #%d", s.title, s.Number)
+	if s.Number == 0 {
+		heading = s.title
+	}
+	drawText(img, strings.ToUpper(heading), cardPadding, cardPadding, textScale, cardText)
+	result := fmt.Sprintf("SOLVED IN %d", s.Attempts)
+	if s.GaveUp {
+		result = "GAVE UP"
+	}
+	drawText(img, result, cardPadding, cardPadding+glyphHeight*textScale+30, smallScale, cardText)
+
+	rows := s.cells
+	if len(rows) > maxCardRows {
+		rows = rows[len(rows)-maxCardRows:]
+	}
+	columns := 0
+	if len(rows) > 0 {
+		columns = len(rows[0])
+	}
+	gridWidth := columns*cellSize + max(columns-1, 0)*cellGap
+	gridHeight := len(rows)*cellSize + max(len(rows)-1, 0)*cellGap
+	left := cardWidth - cardPadding - gridWidth
+	top := (cardHeight - gridHeight) / 2
+	for i, row := range rows {
+		for j, cell := range row {
+			x := left + j*(cellSize+cellGap)
+			y := top + i*(cellSize+cellGap)
+			drawCell(img, image.Rect(x, y, x+cellSize, y+cellSize), cell)
+		}
+	}
+	return img
+}
+
+// drawCell draws a grid cell, with an arrow pointing to the answer's release year.
+func drawCell(img *image.RGBA, rect image.Rectangle, cell int) {
+	draw.Draw(img, rect, image.NewUniform(cellColors[cell]), image.Point{}, draw.Src)
+	if cell != cellHigher && cell != cellLower {
+		return
+	}
+
+	// triangle pointing up or down, filling the middle half of the cell
+	size := rect.Dx() / 2
+	for row := 0; row < size; row++ {
+		width := row
+		y := rect.Min.Y + rect.Dy()/4 + row
+		if cell == cellLower {
+			y = rect.Max.Y - rect.Dy()/4 - row - 1
+		}
+		for x := rect.Min.X + rect.Dx()/2 - width/2; x <= rect.Min.X+rect.Dx()/2+width/2; x++ {
+			img.Set(x, y, cardText)
+		}
+	}
+}
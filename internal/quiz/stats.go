@@ -0,0 +1,98 @@
+package quiz
+
+import (
+	"context"
+	"log"
+)
+
+// PlayerStats holds the results of a player on the daily quizzes of an edition.
+type PlayerStats struct {
+	PlayerID      string      `json:"player_id"`
+	GamesPlayed   int         `json:"games_played"`
+	GamesWon      int         `json:"games_won"`
+	WinRate       float64     `json:"win_rate"` // from 0 to 1
+	CurrentStreak int         `json:"current_streak"`
+	MaxStreak     int         `json:"max_streak"`
+	Distribution  map[int]int `json:"guess_distribution"`    // number of wins by number of guesses
+	LastPlayed    int         `json:"last_played,omitempty"` // number of the last daily quiz played
+	LastWon       int         `json:"last_won,omitempty"`    // number of the last daily quiz won
+}
+
+// GetStats returns the statistics of a player on the daily quizzes of an edition.
+//
+// Parameters:
+//   - playerID: The anonymous ID of the player.
+//   - edition: The daily quiz series.
+//
+// Returns:
+//   - A PlayerStats object, empty when the player never played the edition.
+//   - An error if the category or difficulty doesn't exist or the stats can't be read.
+func (s *service) GetStats(ctx context.Context, playerID string, edition Edition) (PlayerStats, error) {
+	if _, err := s.category(edition); err != nil {
+		return PlayerStats{}, err
+	}
+
+	stats, err := s.repository.GetStats(ctx, playerID, edition)
+	if err != nil {
+		log.Printf("Error getting stats of player %s: %v", playerID, err)
+		return PlayerStats{}, err
+	}
+	stats.PlayerID = playerID
+	if stats.Distribution == nil {
+		stats.Distribution = map[int]int{}
+	}
+
+	// the streak is broken once a day goes by without winning
+	if stats.LastWon < QuizNumber(s.today())-1 {
+		stats.CurrentStreak = 0
+	}
+	return stats, nil
+}
+
+// recordGame updates the statistics of a player after a guess on a quiz, or after
+// giving it up, which breaks the streak. Only
+// today's daily quizzes count, so replays and practice quizzes don't change them.
+//
+// Parameters:
+//   - quiz: The quiz played.
+//   - session: The session of the player on the quiz, after the guess.
+//
+// Returns:
+//   - An error if the stats can't be read or stored.
+func (s *service) recordGame(ctx context.Context, quiz Quiz, session Session) error {
+	if quiz.ID != s.TodaysQuizID(quiz.Edition()) {
+		return nil
+	}
+
+	edition := quiz.Edition()
+	stats, err := s.repository.GetStats(ctx, session.ID, edition)
+	if err != nil {
+		return err
+	}
+	if stats.Distribution == nil {
+		stats.Distribution = map[int]int{}
+	}
+
+	// the game counts as played from its first guess
+	if stats.LastPlayed != quiz.Number {
+		stats.LastPlayed = quiz.Number
+		stats.GamesPlayed++
+	}
+	if session.Solved && stats.LastWon != quiz.Number {
+		if stats.LastWon == quiz.Number-1 {
+			stats.CurrentStreak++
+		} else {
+			stats.CurrentStreak = 1
+		}
+		stats.MaxStreak = max(stats.MaxStreak, stats.CurrentStreak)
+		stats.LastWon = quiz.Number
+		stats.GamesWon++
+		stats.Distribution[len(session.Guesses)]++
+	}
+	if session.GaveUp {
+		stats.CurrentStreak = 0
+	}
+	stats.WinRate = float64(stats.GamesWon) / float64(stats.GamesPlayed)
+
+	return s.repository.SetStats(ctx, session.ID, edition, stats)
+}
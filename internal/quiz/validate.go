@@ -0,0 +1,135 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+)
+
+// maxValidationAttempts bounds the generations of a quiz rejected by the validation.
+const maxValidationAttempts = 5
+
+// Reasons a generated quiz is rejected before being published.
+var (
+	ErrMissingField    = errors.New("quiz is missing a required field")
+	ErrMissingImage    = errors.New("quiz album has no image")
+	ErrMissingGenres   = errors.New("quiz artists have no genres")
+	ErrMissingPreview  = errors.New("quiz track has no audio preview")
+	ErrExplicitContent = errors.New("quiz track is explicit")
+	ErrShortPreview    = errors.New("quiz track audio preview is too short")
+)
+
+// ErrNoValidQuiz is returned when every generation attempt of a quiz
+// was rejected by the validation, holding the reason of each rejection.
+type ErrNoValidQuiz struct {
+	Attempts int
+	Reasons  []error
+}
+
+func (e *ErrNoValidQuiz) Error() string {
+	reasons := make([]string, len(e.Reasons))
+	for i, reason := range e.Reasons {
+		reasons[i] = reason.Error()
+	}
+	return fmt.Sprintf("no valid quiz generated after %d attempts: %s", e.Attempts, strings.Join(reasons, "; "))
+}
+
+// Unwrap returns the reasons of the rejections, so they can be checked with errors.Is.
+func (e *ErrNoValidQuiz) Unwrap() []error {
+	return e.Reasons
+}
+
+// generateValidQuiz generates quizzes until one passes the validation, giving up
+// after maxValidationAttempts. Errors of the generation itself are returned right away.
+//
+// Parameters:
+//   - generate: The generator of the quizzes of the edition.
+//   - r: The random source driving every choice of the generation.
+//   - category: The category constraining the picked track.
+//
+// Returns:
+//   - A Quiz object that passed the validation, with its artists deduplicated.
+//   - An ErrNoValidQuiz error if every attempt was rejected, or the error of the generation.
+func (s *service) generateValidQuiz(generate quizGenerator, r *rand.Rand, category Category) (Quiz, error) {
+	var reasons []error
+	for attempts := 0; attempts < maxValidationAttempts; attempts++ {
+		quiz, err := generate(r, category)
+		if err != nil {
+			return Quiz{}, err
+		}
+
+		quiz.Artists = dedupeArtists(quiz.Artists)
+		err = s.validateQuiz(quiz)
+		if err == nil {
+			return quiz, nil
+		}
+		log.Printf("Generated quiz rejected: %v, retrying", err)
+		reasons = append(reasons, err)
+	}
+	return Quiz{}, &ErrNoValidQuiz{Attempts: maxValidationAttempts, Reasons: reasons}
+}
+
+// validateQuiz checks that a generated quiz can be played, before it's published.
+//
+// Parameters:
+//   - quiz: The generated quiz.
+//
+// Returns:
+//   - An error wrapping the reason the quiz is rejected, nil if it's valid.
+func (s *service) validateQuiz(quiz Quiz) error {
+	required := []struct{ field, value string }{
+		{"track id", quiz.Track.ID},
+		{"track name", quiz.Track.Name},
+		{"album id", quiz.Album.ID},
+		{"album name", quiz.Album.Name},
+		{"album release date", quiz.Album.ReleaseDate},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			return fmt.Errorf("%w: %s", ErrMissingField, r.field)
+		}
+	}
+	if len(quiz.Artists) == 0 {
+		return fmt.Errorf("%w: artists", ErrMissingField)
+	}
+	for _, artist := range quiz.Artists {
+		if artist.ID == "" || strings.TrimSpace(artist.Name) == "" {
+			return fmt.Errorf("%w: artist id or name", ErrMissingField)
+		}
+	}
+
+	if quiz.Album.Image == "" {
+		return ErrMissingImage
+	}
+	// the genres are a hint and the feedback of every guess
+	if len(quiz.Genres()) == 0 {
+		return ErrMissingGenres
+	}
+	if quiz.Track.AudioPreview == "" {
+		return ErrMissingPreview
+	}
+	// the preview is only downloaded in audio mode, where it must last for the longest clip
+	if quiz.preview != nil && mp3Duration(quiz.preview) < float64(audioClipSeconds[len(audioClipSeconds)-1]) {
+		return ErrShortPreview
+	}
+	if s.filterExplicit && quiz.Track.Explicit {
+		return ErrExplicitContent
+	}
+	return nil
+}
+
+// dedupeArtists removes the artists listed more than once, keeping their first position.
+func dedupeArtists(artists []quizArtist) []quizArtist {
+	seen := make(map[string]bool)
+	deduped := []quizArtist{}
+	for _, artist := range artists {
+		if seen[artist.ID] {
+			continue
+		}
+		seen[artist.ID] = true
+		deduped = append(deduped, artist)
+	}
+	return deduped
+}
@@ -1,5 +1,10 @@
 package spotify
 
+import "errors"
+
+// ErrPlaylistNotFound is returned when a playlist doesn't exist or isn't public.
+var ErrPlaylistNotFound = errors.New("playlist not found")
+
 type ErrRecommendationsEmpty struct {
 	Message string
 }
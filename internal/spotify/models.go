@@ -9,9 +9,12 @@ type Service interface {
 	GetAlbums(albumIds []string) (AlbumResponse, error)
 	GetTracks(trackIds []string) (TrackResponse, error)
 	GetArtists(artistIds []string) (ArtistResponse, error)
-	Search(query, queryType string) (SearchResponse, error)
+	Search(query, queryType string, opts ...RequestOption) (SearchResponse, error)
 	RandomSearch(queryType string) (SearchResponse, error)
-	GetRecommendations(seedArtists, seedGenres, seedTracks []string, popularity int) (RecommendationsResponse, error)
+	GetRecommendations(seedArtists, seedGenres, seedTracks []string, popularity int, opts ...RequestOption) (RecommendationsResponse, error)
+	GetArtistTopTracks(artistID string, opts ...RequestOption) (TrackResponse, error)
+	GetRelatedArtists(artistID string) (ArtistResponse, error)
+	GetPlaylistTracks(playlistID string, opts ...RequestOption) (TrackResponse, error)
 }
 
 type Token struct {
@@ -28,11 +31,16 @@ type SpotifyAuthResponse struct {
 type Album struct {
 	ID          string             `json:"id"`
 	Name        string             `json:"name"`
+	AlbumType   string             `json:"album_type"` // album, single or compilation
+	TotalTracks int                `json:"total_tracks"`
 	Artists     []SimplifiedArtist `json:"artists"`
 	ReleaseDate string             `json:"release_date"`
 	Images      []struct {
 		URL string `json:"url"`
 	} `json:"images"`
+	// only returned with the full album object, not with the albums of tracks or searches
+	Label      string `json:"label"`
+	Popularity int    `json:"popularity"`
 }
 type AlbumResponse struct {
 	Albums []Album `json:"albums"`
@@ -43,6 +51,8 @@ type Track struct {
 	Album      Album  `json:"album"`
 	Name       string `json:"name"`
 	PreviewURL string `json:"preview_url"`
+	Popularity int    `json:"popularity"`
+	Explicit   bool   `json:"explicit"`
 }
 type TrackResponse struct {
 	Tracks []Track `json:"tracks"`
@@ -55,6 +65,7 @@ type Artist struct {
 	Followers struct {
 		Total int `json:"total"`
 	} `json:"followers"`
+	Popularity int `json:"popularity"`
 }
 type SimplifiedArtist struct {
 	ID   string `json:"id"`
@@ -76,6 +87,16 @@ type SearchResponse struct {
 	} `json:"artists"`
 }
 
+// PlaylistTracksResponse is a page of the tracks of a playlist.
+type PlaylistTracksResponse struct {
+	Items []struct {
+		IsLocal bool   `json:"is_local"` // uploaded by the owner, unknown to Spotify
+		Track   *Track `json:"track"`    // null when removed from Spotify
+	} `json:"items"`
+	Next  string `json:"next"` // URL of the next page, empty on the last one
+	Total int    `json:"total"`
+}
+
 type RecommendationsResponse struct {
 	Tracks []Track `json:"tracks"`
 }
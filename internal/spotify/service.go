@@ -11,12 +11,17 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	spotifyBaseURL  = "https://api.spotify.com/v1"
 	spotifyTokenURL = "https://accounts.spotify.com/api/token"
+
+	playlistPageSize = 100 // the most tracks of a playlist returned by a request
+	// maxPlaylistTracks bounds the tracks read from a playlist, some holding thousands.
+	maxPlaylistTracks = 1000
 )
 
 type service struct {
@@ -24,14 +29,73 @@ type service struct {
 	token               Token
 	spotifyClientID     string
 	spotifyClientSecret string
+	rand                *rand.Rand
+	randMu              sync.Mutex // rand.Rand is not safe for concurrent use
+}
+
+// Option configures optional settings of the Spotify service.
+type Option func(*service)
+
+// WithRand sets the random source used by RandomSearch. Defaults to a randomly seeded source.
+func WithRand(r *rand.Rand) Option {
+	return func(s *service) {
+		s.rand = r
+	}
 }
 
-func NewService(spotifyClientID, spotifyClientSecret string) *service {
-	return &service{
+func NewService(spotifyClientID, spotifyClientSecret string, opts ...Option) *service {
+	s := &service{
 		client:              &http.Client{},
 		token:               Token{},
 		spotifyClientID:     spotifyClientID,
 		spotifyClientSecret: spotifyClientSecret,
+		rand:                rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// RequestOption sets an optional query parameter of a request to Spotify's API.
+type RequestOption func(params url.Values)
+
+// WithMarket restricts the results to the ones available in
+// the given market. (ISO 3166-1 alpha-2 country code)
+func WithMarket(market string) RequestOption {
+	return func(params url.Values) {
+		if market != "" {
+			params.Set("market", market)
+		}
+	}
+}
+
+// WithLimit sets the maximum number of results.
+func WithLimit(limit int) RequestOption {
+	return func(params url.Values) {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+}
+
+// WithOffset sets the index of the first result, to page through the results.
+func WithOffset(offset int) RequestOption {
+	return func(params url.Values) {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+}
+
+// WithMaxPopularity sets the maximum popularity of the recommended tracks, ignored when 0.
+func WithMaxPopularity(popularity int) RequestOption {
+	return func(params url.Values) {
+		if popularity != 0 {
+			params.Set("max_popularity", strconv.Itoa(popularity))
+		}
+	}
+}
+
+func applyOptions(params url.Values, opts []RequestOption) {
+	for _, opt := range opts {
+		opt(params)
 	}
 }
 
@@ -194,10 +258,12 @@ func (spotify *service) GetArtists(artistIds []string) (ArtistResponse, error) {
 //
 //   - queryType: The type of search query to perform. (track, album, artist)
 //
+//   - opts: Optional query parameters, such as the market.
+//
 // Returns:
 //   - A SearchResponse object containing the search results.
 //   - An error if the request or data parsing fails.
-func (spotify *service) Search(query, queryType string) (SearchResponse, error) {
+func (spotify *service) Search(query, queryType string, opts ...RequestOption) (SearchResponse, error) {
 	url := spotifyBaseURL + "/search"
 	var searchResponse SearchResponse
 
@@ -214,6 +280,7 @@ func (spotify *service) Search(query, queryType string) (SearchResponse, error)
 	params := req.URL.Query()
 	params.Set("q", query)
 	params.Set("type", queryType)
+	applyOptions(params, opts)
 	req.URL.RawQuery = params.Encode()
 
 	log.Println("Searching for:", query)
@@ -252,8 +319,21 @@ func (spotify *service) Search(query, queryType string) (SearchResponse, error)
 //   - A SearchResponse object containing the search results.
 //   - An error if the request or data parsing fails.
 func (s *service) RandomSearch(queryType string) (SearchResponse, error) {
-	r := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	s.randMu.Lock()
+	randomWildcard := RandomWildcard(s.rand)
+	s.randMu.Unlock()
+
+	return s.Search(randomWildcard, queryType)
+}
 
+// RandomWildcard picks a random wildcard query to be used in a search.
+//
+// Parameters:
+//   - r: The random source used to pick the wildcard.
+//
+// Returns:
+//   - A wildcard query such as %ab, %ab% or ab%.
+func RandomWildcard(r *rand.Rand) string {
 	// since spotify doesn't have a random search,
 	// we can use wildcards to search for an *almost*
 	// random result
@@ -276,9 +356,7 @@ func (s *service) RandomSearch(queryType string) (SearchResponse, error) {
 			wildcards = append(wildcards, "%"+combination, "%"+combination+"%", combination+"%")
 		}
 	}
-	randomWildcard := wildcards[r.IntN(len(wildcards))]
-
-	return s.Search(randomWildcard, queryType)
+	return wildcards[r.IntN(len(wildcards))]
 }
 
 // GetRecommendations retrieves recommendations from Spotify's API based on the given seed parameters.
@@ -288,11 +366,12 @@ func (s *service) RandomSearch(queryType string) (SearchResponse, error) {
 //   - seedGenres: A slice of genre names to use as seed genres. max 5
 //   - seedTracks: A slice of track IDs to use as seed tracks. max 5
 //   - popularity: The minimum popularity of the recommendations. (0-100)
+//   - opts: Optional query parameters, such as the market. (defaults to US)
 //
 // Returns:
 //   - A RecommendationsResponse object containing the recommendations.
 //   - An error if the request or data parsing fails.
-func (s *service) GetRecommendations(seedArtists, seedGenres, seedTracks []string, popularity int) (RecommendationsResponse, error) {
+func (s *service) GetRecommendations(seedArtists, seedGenres, seedTracks []string, popularity int, opts ...RequestOption) (RecommendationsResponse, error) {
 	if len(seedArtists) == 0 && len(seedGenres) == 0 && len(seedTracks) == 0 {
 		return RecommendationsResponse{}, errors.New("at least one seed parameter is required")
 	}
@@ -328,6 +407,7 @@ func (s *service) GetRecommendations(seedArtists, seedGenres, seedTracks []strin
 	}
 	params.Set("min_popularity", strconv.Itoa(popularity))
 	params.Set("market", "US")
+	applyOptions(params, opts)
 	req.URL.RawQuery = params.Encode()
 
 	req.Header.Add("Authorization", "Bearer "+token.AccessToken)
@@ -348,7 +428,7 @@ func (s *service) GetRecommendations(seedArtists, seedGenres, seedTracks []strin
 
 		log.Println("Rate limited by Spotify, waiting ", waitTime, "seconds")
 		time.Sleep(time.Second * time.Duration(waitTime))
-		return s.GetRecommendations(seedArtists, seedGenres, seedTracks, popularity)
+		return s.GetRecommendations(seedArtists, seedGenres, seedTracks, popularity, opts...)
 	} else if res.StatusCode != http.StatusOK {
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
@@ -368,3 +448,188 @@ func (s *service) GetRecommendations(seedArtists, seedGenres, seedTracks []strin
 	}
 	return recommendationsResponse, nil
 }
+
+// GetArtistTopTracks retrieves the top tracks of an artist from Spotify's API.
+//
+// Parameters:
+//   - artistID: The ID of the artist.
+//   - opts: Optional query parameters, such as the market. (defaults to US)
+//
+// Returns:
+//   - A TrackResponse object containing the artist's top tracks.
+//   - An error if the request or data parsing fails.
+func (s *service) GetArtistTopTracks(artistID string, opts ...RequestOption) (TrackResponse, error) {
+	url := spotifyBaseURL + "/artists/" + url.PathEscape(artistID) + "/top-tracks"
+	var trackResponse TrackResponse
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return trackResponse, err
+	}
+
+	token, err := s.getAccessToken()
+	if err != nil {
+		return trackResponse, err
+	}
+
+	params := req.URL.Query()
+	params.Set("market", "US")
+	applyOptions(params, opts)
+	req.URL.RawQuery = params.Encode()
+
+	req.Header.Add("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Add("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return trackResponse, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return trackResponse, errors.New("Spotify HTTP Status: " + res.Status)
+		}
+
+		return trackResponse, errors.New("Spotify HTTP Status: " + res.Status + "\n" + string(body))
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&trackResponse)
+	if err != nil {
+		return trackResponse, err
+	}
+
+	return trackResponse, nil
+}
+
+// GetRelatedArtists retrieves the artists similar to an artist from Spotify's API,
+// based on the listening history of Spotify's users.
+//
+// Parameters:
+//   - artistID: The ID of the artist.
+//
+// Returns:
+//   - An ArtistResponse object containing up to 20 related artists.
+//   - An error if the request or data parsing fails.
+func (s *service) GetRelatedArtists(artistID string) (ArtistResponse, error) {
+	url := spotifyBaseURL + "/artists/" + url.PathEscape(artistID) + "/related-artists"
+	var artistResponse ArtistResponse
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return artistResponse, err
+	}
+
+	token, err := s.getAccessToken()
+	if err != nil {
+		return artistResponse, err
+	}
+
+	req.Header.Add("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Add("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return artistResponse, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return artistResponse, errors.New("Spotify HTTP Status: " + res.Status)
+		}
+
+		return artistResponse, errors.New("Spotify HTTP Status: " + res.Status + "\n" + string(body))
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&artistResponse)
+	if err != nil {
+		return artistResponse, err
+	}
+
+	return artistResponse, nil
+}
+
+// GetPlaylistTracks retrieves the tracks of a public playlist from Spotify's API, paging
+// through the playlist up to maxPlaylistTracks. Local files, podcast episodes and the
+// tracks removed from Spotify are left out.
+//
+// Parameters:
+//   - playlistID: The ID of the playlist.
+//   - opts: Optional query parameters, such as the market. (defaults to US)
+//
+// Returns:
+//   - A TrackResponse object containing the tracks in the order of the playlist.
+//   - ErrPlaylistNotFound if the playlist doesn't exist or isn't public, or an error if the request or data parsing fails.
+func (s *service) GetPlaylistTracks(playlistID string, opts ...RequestOption) (TrackResponse, error) {
+	trackResponse := TrackResponse{Tracks: []Track{}}
+	for offset := 0; offset < maxPlaylistTracks; offset += playlistPageSize {
+		page, err := s.getPlaylistPage(playlistID, offset, opts)
+		if err != nil {
+			return trackResponse, err
+		}
+
+		for _, item := range page.Items {
+			// episodes have no album
+			if item.IsLocal || item.Track == nil || item.Track.ID == "" || item.Track.Album.ID == "" {
+				continue
+			}
+			trackResponse.Tracks = append(trackResponse.Tracks, *item.Track)
+		}
+		if page.Next == "" {
+			break
+		}
+	}
+
+	return trackResponse, nil
+}
+
+// getPlaylistPage retrieves a page of the tracks of a playlist, starting at offset.
+func (s *service) getPlaylistPage(playlistID string, offset int, opts []RequestOption) (PlaylistTracksResponse, error) {
+	url := spotifyBaseURL + "/playlists/" + url.PathEscape(playlistID) + "/tracks"
+	var page PlaylistTracksResponse
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return page, err
+	}
+
+	token, err := s.getAccessToken()
+	if err != nil {
+		return page, err
+	}
+
+	params := req.URL.Query()
+	params.Set("market", "US")
+	applyOptions(params, opts)
+	// the page overrides any limit or offset of the options
+	applyOptions(params, []RequestOption{WithLimit(playlistPageSize), WithOffset(offset)})
+	req.URL.RawQuery = params.Encode()
+
+	req.Header.Add("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Add("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return page, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return page, ErrPlaylistNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return page, errors.New("Spotify HTTP Status: " + res.Status)
+		}
+
+		return page, errors.New("Spotify HTTP Status: " + res.Status + "\n" + string(body))
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&page)
+	if err != nil {
+		return page, err
+	}
+
+	return page, nil
+}
@@ -1,7 +1,10 @@
 package spotify
 
 import (
+	"errors"
+	"math/rand/v2"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/joho/godotenv"
@@ -175,8 +178,12 @@ func TestGetAlbums(t *testing.T) {
 				t.Errorf("Error getting album: %v", err)
 				return
 			}
-			if albumResponse.Albums[0].Name != tc.expected {
-				t.Errorf("Expected album name to be %s, got %s", tc.expected, albumResponse.Albums[0].Name)
+			album := albumResponse.Albums[0]
+			if album.Name != tc.expected {
+				t.Errorf("Expected album name to be %s, got %s", tc.expected, album.Name)
+			}
+			if album.AlbumType != "album" || album.TotalTracks == 0 || album.Label == "" {
+				t.Errorf("Expected album type, total tracks and label to be set, got %+v", album)
 			}
 		})
 	}
@@ -266,3 +273,58 @@ func TestGetRecommendations(t *testing.T) {
 		t.Errorf("Error getting recommendations: %v", err)
 	}
 }
+
+func TestRandomWildcard(t *testing.T) {
+	first := RandomWildcard(rand.New(rand.NewPCG(1, 2)))
+	second := RandomWildcard(rand.New(rand.NewPCG(1, 2)))
+	if first != second {
+		t.Errorf("Expected the same seed to pick the same wildcard, got %s and %s", first, second)
+	}
+	if !strings.Contains(first, "%") {
+		t.Errorf("Expected a wildcard query, got %s", first)
+	}
+}
+
+func TestGetArtistTopTracks(t *testing.T) {
+	spotifyService = NewService(os.Getenv("SPOTIFY_CLIENT_ID"), os.Getenv("SPOTIFY_CLIENT_SECRET"))
+
+	trackResponse, err := spotifyService.GetArtistTopTracks("0k17h0D3J5VfsdmQ1iZtE9")
+	if err != nil {
+		t.Errorf("Error getting artist top tracks: %v", err)
+		return
+	}
+	if len(trackResponse.Tracks) == 0 {
+		t.Errorf("Expected artist to have top tracks, got 0")
+	}
+}
+
+func TestGetRelatedArtists(t *testing.T) {
+	spotifyService = NewService(os.Getenv("SPOTIFY_CLIENT_ID"), os.Getenv("SPOTIFY_CLIENT_SECRET"))
+
+	artistResponse, err := spotifyService.GetRelatedArtists("0k17h0D3J5VfsdmQ1iZtE9")
+	if err != nil {
+		t.Errorf("Error getting related artists: %v", err)
+		return
+	}
+	if len(artistResponse.Artists) == 0 {
+		t.Errorf("Expected artist to have related artists, got 0")
+	}
+}
+
+func TestGetPlaylistTracks(t *testing.T) {
+	spotifyService = NewService(os.Getenv("SPOTIFY_CLIENT_ID"), os.Getenv("SPOTIFY_CLIENT_SECRET"))
+
+	// a public playlist of more than one page of tracks
+	trackResponse, err := spotifyService.GetPlaylistTracks("37i9dQZF1DWXRqgorJj26U")
+	if err != nil {
+		t.Errorf("Error getting playlist tracks: %v", err)
+		return
+	}
+	if len(trackResponse.Tracks) <= playlistPageSize {
+		t.Errorf("Expected every page of the playlist, got %d tracks", len(trackResponse.Tracks))
+	}
+
+	if _, err := spotifyService.GetPlaylistTracks("0000000000000000000000"); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Errorf("Expected ErrPlaylistNotFound for an unknown playlist, got %v", err)
+	}
+}
@@ -1,11 +1,10 @@
 package routes
 
 import (
+	"expvar"
 	"fmt"
 	"net/http"
-	"os"
 
-	"backendProject/internal/db"
 	"backendProject/internal/quiz"
 	"backendProject/internal/spotify"
 	"backendProject/internal/websocket"
@@ -18,7 +17,7 @@ const (
 	baseURL = "/api/v1"
 )
 
-func NewRouter(db db.Database) *chi.Mux {
+func NewRouter(spotifyService spotify.Service, quizService quiz.Service) *chi.Mux {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 
@@ -27,8 +26,10 @@ func NewRouter(db db.Database) *chi.Mux {
 		fmt.Fprintf(w, `{"message": "Hello World!"}`)
 	})
 
+	// Metrics
+	r.Handle("/debug/vars", expvar.Handler())
+
 	// Spotify
-	spotifyService := spotify.NewService(os.Getenv("SPOTIFY_CLIENT_ID"), os.Getenv("SPOTIFY_CLIENT_SECRET"))
 	spotifyHandler := spotify.NewHandler(spotifyService)
 
 	r.Get("/albums", spotifyHandler.GetAlbumsHandler)
@@ -37,11 +38,40 @@ func NewRouter(db db.Database) *chi.Mux {
 	r.Get("/search", spotifyHandler.SearchHandler)
 
 	// Quiz
-	quizRepository := quiz.NewRepository(db)
-	quizService := quiz.NewService(quizRepository, spotifyService)
 	quizHandler := quiz.NewHandler(quizService)
 
 	r.Get(baseURL+"/quiz", quizHandler.GetTodaysQuizHandler)
+	r.Post(baseURL+"/quiz/guess", quizHandler.GuessHandler)
+	r.Post(baseURL+"/quiz/give-up", quizHandler.GiveUpHandler)
+	r.Post(baseURL+"/quiz/skip", quizHandler.SkipHandler)
+	r.Get(baseURL+"/quiz/cover", quizHandler.CoverHandler)
+	r.Get(baseURL+"/quiz/audio", quizHandler.AudioHandler)
+	r.Get(baseURL+"/quiz/archive", quizHandler.ListQuizzesHandler)
+	r.Get(baseURL+"/quiz/categories", quizHandler.ListCategoriesHandler)
+	r.Get(baseURL+"/quiz/autocomplete", quizHandler.AutocompleteHandler)
+	r.Get(baseURL+"/quiz/share", quizHandler.ShareHandler)
+	r.Get(baseURL+"/quiz/share.png", quizHandler.ShareImageHandler)
+	r.Get(baseURL+"/quiz/stats", quizHandler.QuizStatsHandler)
+	r.Get(baseURL+"/quiz/leaderboard", quizHandler.LeaderboardHandler)
+	r.Post(baseURL+"/quiz/practice", quizHandler.PracticeHandler)
+	r.Post(baseURL+"/quiz/from-playlist", quizHandler.PlaylistQuizHandler)
+	r.Post(baseURL+"/quiz/sessions", quizHandler.CreateQuizSessionHandler)
+	r.Post(baseURL+"/quiz/sessions/{id}/next", quizHandler.NextRoundHandler)
+	r.Get(baseURL+"/quiz/sessions/{id}", quizHandler.GetQuizSessionHandler)
+	r.Post(baseURL+"/quizzes", quizHandler.CreateCustomQuizHandler)
+	r.Get(baseURL+"/quizzes/{code}", quizHandler.GetCustomQuizHandler)
+	r.Get(baseURL+"/quiz/{id}", quizHandler.GetQuizHandler)
+	r.Get(baseURL+"/quiz/{id}/answer", quizHandler.GetAnswerHandler)
+	r.Post(baseURL+"/quiz/{id}/guess", quizHandler.GuessHandler)
+	r.Post(baseURL+"/quiz/{id}/give-up", quizHandler.GiveUpHandler)
+	r.Post(baseURL+"/quiz/{id}/skip", quizHandler.SkipHandler)
+	r.Get(baseURL+"/quiz/{id}/cover", quizHandler.CoverHandler)
+	r.Get(baseURL+"/quiz/{id}/audio", quizHandler.AudioHandler)
+	r.Get(baseURL+"/quiz/{id}/share", quizHandler.ShareHandler)
+	r.Get(baseURL+"/quiz/{id}/share.png", quizHandler.ShareImageHandler)
+	r.Get(baseURL+"/quiz/{id}/stats", quizHandler.QuizStatsHandler)
+	r.Get(baseURL+"/quiz/{id}/leaderboard", quizHandler.LeaderboardHandler)
+	r.Get(baseURL+"/me/stats", quizHandler.StatsHandler)
 
 	// Websocket
 	websocketHandler := websocket.NewHandler()